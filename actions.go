@@ -1,102 +1,659 @@
 package daemonigo
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 )
 
-// Daemon default actions.
-// Can be changed with SetAction() and RemoveAction() functions.
-var actions = map[string]func(){
-	"start": func() {
-		switch isRunning, _, err := Status(); {
-		case err != nil:
-			printStatusErr(err)
-		case isRunning:
-			fmt.Println(AppName + " is already started and running now")
-		default:
-			start()
-		}
-	},
-	"stop": func() {
-		switch isRunning, process, err := Status(); {
-		case err != nil:
-			printStatusErr(err)
-		case !isRunning:
-			fmt.Println(AppName + " is NOT running or already stopped")
-		default:
-			stop(process)
-		}
-	},
-	"status": func() {
-		switch isRunning, process, err := Status(); {
-		case err != nil:
-			printStatusErr(err)
-		case !isRunning:
-			fmt.Println(AppName + " is NOT running")
-		default:
-			fmt.Printf("%s is running with PID %d\n", AppName, process.Pid)
-		}
-	},
-	"restart": func() {
-		isRunning, process, err := Status()
-		if err != nil {
-			printStatusErr(err)
-			return
+// Creates default actions of given daemon.
+// Can be changed with RegisterAction(), SetAction() and RemoveAction()
+// methods, or modified via LookupAction() method.
+//
+// Errors returned by actions are already printed, so they only
+// define exit code of application (see ExitCode()).
+func defaultActions(d *Daemon) []*Action {
+	return []*Action{
+		{
+			Name: "start",
+			Help: "Start daemon in background",
+			Run: func([]string) error {
+				switch isRunning, _, err := d.Status(); {
+				case err != nil:
+					return d.printStatusErr(err)
+				case isRunning:
+					fmt.Println(
+						d.AppName + " is already started and running now",
+					)
+					return nil
+				default:
+					return d.start()
+				}
+			},
+		},
+		{
+			Name: "stop",
+			Help: "Stop running daemon",
+			Run: func([]string) error {
+				switch isRunning, process, err := d.Status(); {
+				case err != nil:
+					return d.printStatusErr(err)
+				case !isRunning:
+					fmt.Println(
+						d.AppName + " is NOT running or already stopped",
+					)
+					return nil
+				default:
+					return d.stop(process)
+				}
+			},
+		},
+		d.statusAction(),
+		{
+			Name: foregroundAction,
+			Help: "Run daemon in foreground",
+			Run: func([]string) error {
+				return d.runForeground()
+			},
+		},
+		{
+			Name: "restart",
+			Help: "Stop daemon if it is running and start it again",
+			Run: func([]string) error {
+				isRunning, process, err := d.Status()
+				if err != nil {
+					return d.printStatusErr(err)
+				}
+				if isRunning {
+					if err := d.stop(process); err != nil {
+						return err
+					}
+				}
+				if d.foregroundForced() &&
+					d.LookupAction(foregroundAction) != nil {
+					return d.runForeground()
+				}
+				return d.start()
+			},
+		},
+		{
+			Name: "reload",
+			Help: "Replace running daemon process without downtime",
+			Run: func([]string) error {
+				switch isRunning, process, err := d.Status(); {
+				case err != nil:
+					return d.printStatusErr(err)
+				case !isRunning:
+					fmt.Println(d.AppName + " is NOT running now")
+					return d.start()
+				case d.ReloadSignal == nil:
+					err := fmt.Errorf(
+						"reloading is not configured for %s", d.AppName,
+					)
+					fmt.Println(err.Error())
+					return newActionError(ExitUnimplemented, err)
+				default:
+					return d.reload(process)
+				}
+			},
+		},
+		{
+			Name: "print-unit",
+			Help: "Print systemd unit files of daemon",
+			Run: func([]string) error {
+				units, err := d.SystemdUnits()
+				if err != nil {
+					fmt.Printf(
+						"Generating systemd units of %s failed\n", d.AppName,
+					)
+					fmt.Println("Details:", err.Error())
+					return err
+				}
+				for i, unit := range units {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Printf("# %s\n%s", unit.Name, unit.Content)
+				}
+				return nil
+			},
+		},
+		d.installSystemdAction(),
+		d.printInitScriptAction(),
+		d.installInitScriptAction(),
+		d.healthAction(),
+		{
+			Name: "reopen-logs",
+			Help: "Make running daemon reopen its log files",
+			Run: func([]string) error {
+				switch isRunning, process, err := d.Status(); {
+				case err != nil:
+					return d.printStatusErr(err)
+				case !isRunning:
+					fmt.Println(d.AppName + " is NOT running")
+					return newActionError(ExitNotRunning, ErrNotRunning)
+				case d.ReopenSignal == nil:
+					err := fmt.Errorf(
+						"reopening logs is not configured for %s", d.AppName,
+					)
+					fmt.Println(err.Error())
+					return newActionError(ExitUnimplemented, err)
+				default:
+					fmt.Printf("Reopening log files of %s...", d.AppName)
+					if err := d.signal(process, d.ReopenSignal); err != nil {
+						return failed(err)
+					}
+					fmt.Println("OK")
+					return nil
+				}
+			},
+		},
+		d.helpAction(),
+	}
+}
+
+// Creates default "status" action of daemon.
+func (d *Daemon) statusAction() *Action {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text|json")
+	return &Action{
+		Name:  "status",
+		Help:  "Print status of daemon",
+		Flags: flags,
+		Run: func([]string) error {
+			if *format != "text" && *format != "json" {
+				fmt.Println("Unknown status format: " + *format)
+				return newActionError(ExitStatusUnknown, fmt.Errorf(
+					"unknown status format %q", *format,
+				))
+			}
+			info, err := d.StatusDetailed()
+			if err != nil {
+				d.printStatusErr(err)
+				return newActionError(ExitStatusUnknown, err)
+			}
+			switch {
+			case *format == "json":
+				out := json.NewEncoder(os.Stdout)
+				out.SetIndent("", "  ")
+				out.Encode(info)
+			case info.State == StateUnexpected:
+				d.printStatusErr(errors.New(info.Reason))
+			case info.State == StateRunning:
+				fmt.Printf("%s is running with PID %d\n", d.AppName, info.PID)
+				d.printSupervision()
+				d.printControlStatus()
+			case info.State == StateDead:
+				fmt.Println(d.AppName + " is NOT running, but PID file exists")
+				d.printSupervision()
+			default:
+				fmt.Println(d.AppName + " is NOT running")
+				d.printSupervision()
+			}
+			return info.exitError()
+		},
+	}
+}
+
+// Creates default "install-systemd" action of daemon.
+func (d *Daemon) installSystemdAction() *Action {
+	flags := flag.NewFlagSet("install-systemd", flag.ContinueOnError)
+	dir := flags.String("dir", systemdUnitDir, "unit files directory")
+	return &Action{
+		Name:  "install-systemd",
+		Help:  "Install systemd unit files of daemon",
+		Flags: flags,
+		Run: func([]string) error {
+			fmt.Printf("Installing systemd units of %s...", d.AppName)
+			paths, err := d.InstallSystemd(*dir)
+			if err != nil {
+				return failed(err)
+			}
+			fmt.Println("OK")
+			for _, path := range paths {
+				fmt.Println("Written", path)
+			}
+			fmt.Println("Run `systemctl daemon-reload` to apply changes")
+			return nil
+		},
+	}
+}
+
+// Creates default "print-init-script" action of daemon.
+func (d *Daemon) printInitScriptAction() *Action {
+	flags := flag.NewFlagSet("print-init-script", flag.ContinueOnError)
+	format := flags.String("format", InitSysV, "format: sysv|openrc")
+	return &Action{
+		Name:  "print-init-script",
+		Help:  "Print init script of daemon",
+		Flags: flags,
+		Run: func([]string) error {
+			if err := checkInitFormat(*format); err != nil {
+				return err
+			}
+			script, err := d.InitScript(*format)
+			if err != nil {
+				fmt.Printf("Generating init script of %s failed\n", d.AppName)
+				fmt.Println("Details:", err.Error())
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
+
+// Creates default "install-init-script" action of daemon.
+func (d *Daemon) installInitScriptAction() *Action {
+	flags := flag.NewFlagSet("install-init-script", flag.ContinueOnError)
+	format := flags.String("format", InitSysV, "format: sysv|openrc")
+	dir := flags.String("dir", initScriptDir, "init script directory")
+	return &Action{
+		Name:  "install-init-script",
+		Help:  "Install init script of daemon",
+		Flags: flags,
+		Run: func([]string) error {
+			if err := checkInitFormat(*format); err != nil {
+				return err
+			}
+			fmt.Printf("Installing init script of %s...", d.AppName)
+			path, err := d.InstallInitScript(*format, *dir)
+			if err != nil {
+				return failed(err)
+			}
+			fmt.Println("OK")
+			fmt.Println("Written", path)
+			return nil
+		},
+	}
+}
+
+// Helper function which checks init script format given to actions.
+func checkInitFormat(format string) error {
+	if format != InitSysV && format != InitOpenRC {
+		fmt.Println("Unknown init script format: " + format)
+		return newActionError(ExitInvalidArgs, fmt.Errorf(
+			"unknown init script format %q", format,
+		))
+	}
+	return nil
+}
+
+// Creates default "health" action of daemon.
+func (d *Daemon) healthAction() *Action {
+	flags := flag.NewFlagSet("health", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text|json")
+	return &Action{
+		Name:  "health",
+		Help:  "Run health checks of running daemon",
+		Flags: flags,
+		Run: func([]string) error {
+			if *format != "text" && *format != "json" {
+				fmt.Println("Unknown health format: " + *format)
+				return newActionError(ExitInvalidArgs, fmt.Errorf(
+					"unknown health format %q", *format,
+				))
+			}
+			switch isRunning, _, err := d.Status(); {
+			case err != nil:
+				return d.printStatusErr(err)
+			case !isRunning:
+				fmt.Println(d.AppName + " is NOT running")
+				return newActionError(ExitNotRunning, ErrNotRunning)
+			}
+			report, err := d.Health()
+			if err != nil {
+				fmt.Println("Checking health of " + d.AppName + " failed")
+				fmt.Println("Details:", err.Error())
+				return err
+			}
+			if *format == "json" {
+				out := json.NewEncoder(os.Stdout)
+				out.SetIndent("", "  ")
+				out.Encode(report)
+				return report.err()
+			}
+			for _, check := range report.Checks {
+				if check.Healthy {
+					fmt.Printf("%s...OK\n", check.Name)
+				} else {
+					fmt.Printf("%s...FAILED\n", check.Name)
+					fmt.Println("Details:", check.Error)
+				}
+			}
+			if report.Healthy {
+				fmt.Println(d.AppName + " is healthy")
+			} else {
+				fmt.Println(d.AppName + " is NOT healthy")
+			}
+			return report.err()
+		},
+	}
+}
+
+// Helper method to print errors of Status() method.
+// Returns given error for convenience.
+func (d *Daemon) printStatusErr(e error) error {
+	fmt.Println("Checking status of " + d.AppName + " failed")
+	fmt.Println("Details:", e.Error())
+	return e
+}
+
+// Helper method to print state of supervisor in supervised mode.
+func (d *Daemon) printSupervision() {
+	if !d.Supervise {
+		return
+	}
+	state, err := d.Supervision()
+	switch {
+	case err != nil:
+		fmt.Println("Checking supervisor state failed")
+		fmt.Println("Details:", err.Error())
+	case state != nil:
+		fmt.Printf("Supervisor is %s, worker restarts: %d\n",
+			state.State, state.Restarts,
+		)
+		if state.WorkerPid != 0 {
+			fmt.Printf("Worker PID: %d\n", state.WorkerPid)
 		}
-		if isRunning {
-			stop(process)
+		if state.LastExit != "" {
+			fmt.Println("Last worker exit:", state.LastExit)
 		}
-		start()
-	},
+	}
 }
 
-// Helper function to print errors of Status() function.
-func printStatusErr(e error) {
-	fmt.Println("Checking status of " + AppName + " failed")
-	fmt.Println("Details:", e.Error())
+// Helper method to print status reported by daemon via control socket.
+func (d *Daemon) printControlStatus() {
+	if !d.Control {
+		return
+	}
+	reply, err := d.Call("status")
+	if err != nil {
+		fmt.Println("Querying status via control socket failed")
+		fmt.Println("Details:", err.Error())
+		return
+	}
+	fmt.Println(reply)
 }
 
 // Helper function to operate with errors printing in actions.
-func failed(e error) {
+// Returns given error for convenience.
+func failed(e error) error {
 	fmt.Println("FAILED")
 	fmt.Println("Details:", e.Error())
+	return e
 }
 
-// Helper function which wraps Stop() with printing
-// for using in daemon default actions.
-func stop(process *os.Process) {
-	fmt.Printf("Stopping %s...", AppName)
-	if err := Stop(process); err != nil {
+// Helper method which wraps Stop() with printing
+// and lifecycle hooks for using in daemon default actions.
+func (d *Daemon) stop(process *os.Process) error {
+	fmt.Printf("Stopping %s...", d.AppName)
+	if d.Hooks.BeforeStop != nil {
+		if err := d.Hooks.BeforeStop(process); err != nil {
+			return failed(err)
+		}
+	}
+	if err := d.Stop(process); err != nil {
+		return failed(err)
+	}
+	fmt.Println("OK")
+	if d.Hooks.AfterStop != nil {
+		d.Hooks.AfterStop()
+	}
+	return nil
+}
+
+// Helper method which wraps StartContext() with printing
+// and lifecycle hooks for using in daemon default actions.
+func (d *Daemon) start() error {
+	fmt.Printf("Starting %s...", d.AppName)
+	if d.Hooks.BeforeStart != nil {
+		if err := d.Hooks.BeforeStart(); err != nil {
+			return failed(err)
+		}
+	}
+	err := d.StartContext(context.Background(), d.StartTimeout)
+	if err == nil && d.RequireHealthy {
+		if err = d.waitHealthy(d.StartTimeout); err != nil {
+			// unhealthy daemon must not be left running after failed start
+			if _, process, e := d.Status(); e == nil && process != nil {
+				d.StopContext(context.Background(), process)
+			}
+		}
+	}
+	switch {
+	case errors.Is(err, ErrAlreadyRunning):
+		fmt.Println("SKIPPED")
+		fmt.Println(d.AppName + " is already started and running now")
+	case err != nil:
 		failed(err)
-	} else {
+		if d.Hooks.OnStartFailure != nil {
+			d.Hooks.OnStartFailure(err)
+		}
+		return err
+	default:
 		fmt.Println("OK")
+		if d.Hooks.AfterStart != nil {
+			d.Hooks.AfterStart()
+		}
 	}
+	return nil
 }
 
-// Helper function which wraps Start() with printing
+// Helper method which wraps ReloadProcess() with printing
 // for using in daemon default actions.
-func start() {
-	fmt.Printf("Starting %s...", AppName)
-	if err := Start(1); err != nil {
-		failed(err)
-	} else {
-		fmt.Println("OK")
+func (d *Daemon) reload(process *os.Process) error {
+	fmt.Printf("Reloading %s...", d.AppName)
+	if err := d.ReloadProcess(process); err != nil {
+		return failed(err)
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// Helper method which sends given signal to daemon process
+// for using in daemon default actions.
+func (d *Daemon) signal(process *os.Process, sig os.Signal) error {
+	if sig == nil {
+		return fmt.Errorf("signal is not configured for %s", d.AppName)
+	}
+	if err := process.Signal(sig); err != nil {
+		return fmt.Errorf(
+			"failed to send %s signal to %s, reason -> %w",
+			sig, d.AppName, err,
+		)
+	}
+	return nil
+}
+
+// Daemon action performed in parent process, like "start" or "stop".
+type Action struct {
+	// Name of action given on command line.
+	Name string
+
+	// Alternative names of action.
+	Aliases []string
+
+	// Short description of action printed by "help" action.
+	Help string
+
+	// Synopsis of positional arguments printed by "help" action,
+	// like "[action]".
+	ArgsUsage string
+
+	// If set, action is not listed by "help" action,
+	// but still can be performed.
+	Hidden bool
+
+	// Flags of action, which are parsed from command line arguments
+	// following action name. May be nil if action has no flags.
+	// Usage of flag set is replaced to print help of action.
+	Flags *flag.FlagSet
+
+	// Performs action with positional arguments left after parsing Flags.
+	// Error returned by action defines exit code of application
+	// (see ExitCode()), so it may be *ActionError with specific code.
+	Run func(args []string) error
+}
+
+// Reports whether action has given name or alias.
+func (a *Action) is(name string) bool {
+	if a.Name == name {
+		return true
+	}
+	for _, alias := range a.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses given command line arguments of action and performs it.
+func (d *Daemon) perform(action *Action, args []string) error {
+	if action.Flags != nil {
+		action.Flags.Usage = func() {
+			d.printActionHelp(action.Flags.Output(), action)
+		}
+		switch err := action.Flags.Parse(args); {
+		case err == flag.ErrHelp:
+			return nil
+		case err != nil:
+			// LSB reserves all exit codes of status action
+			// for reporting status of daemon
+			code := ExitInvalidArgs
+			if action.Name == "status" {
+				code = ExitStatusUnknown
+			}
+			return newActionError(code, err)
+		}
+		args = action.Flags.Args()
+	}
+	return action.Run(args)
+}
+
+// Registers daemon action or overrides previous with the same name.
+//
+// This function is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func RegisterAction(action *Action) {
+	stdDaemon().RegisterAction(action)
+}
+
+// Registers daemon action or overrides previous with the same name.
+//
+// This method is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func (d *Daemon) RegisterAction(action *Action) {
+	if action == nil {
+		panic("daemonigo.RegisterAction(): action cannot be nil")
+	}
+	if action.Name == "" {
+		panic("daemonigo.RegisterAction(): name cannot be empty")
+	}
+	if action.Run == nil {
+		panic("daemonigo.RegisterAction(): run function cannot be nil")
+	}
+	d.actions[action.Name] = action
+}
+
+// Returns daemon action with given name or alias,
+// or nil if there is no such action.
+// Returned action can be modified to customize default actions.
+//
+// This function is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func LookupAction(name string) *Action {
+	return stdDaemon().LookupAction(name)
+}
+
+// Returns daemon action with given name or alias,
+// or nil if there is no such action.
+// Returned action can be modified to customize default actions.
+//
+// This method is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func (d *Daemon) LookupAction(name string) *Action {
+	if action, exist := d.actions[name]; exist {
+		return action
+	}
+	for _, action := range d.actions {
+		if name != "" && action.is(name) {
+			return action
+		}
 	}
+	return nil
 }
 
 // Sets new daemon action with given name or overrides previous.
+// Action ignores command line arguments, use RegisterAction()
+// for actions with flags, arguments or description.
 //
 // This function is not concurrent safe, so you must synchronize
 // its calls in case of usage in multiple goroutines.
 func SetAction(name string, action func()) {
+	stdDaemon().SetAction(name, action)
+}
+
+// Sets new daemon action with given name or overrides previous.
+// Action ignores command line arguments, use RegisterAction()
+// for actions with flags, arguments or description.
+//
+// This method is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func (d *Daemon) SetAction(name string, action func()) {
 	if name == "" {
 		panic("daemonigo.SetAction(): name cannot be empty")
 	}
 	if action == nil {
 		panic("daemonigo.SetAction(): action cannot be nil")
 	}
-	actions[name] = action
+	d.setActionRun(name, func([]string) error {
+		action()
+		return nil
+	})
+}
+
+// Sets new daemon action with given name or overrides previous.
+// Error returned by action defines exit code of application
+// (see ExitCode()), so it may be *ActionError with specific code.
+//
+// This function is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func SetActionFunc(name string, action func() error) {
+	stdDaemon().SetActionFunc(name, action)
+}
+
+// Sets new daemon action with given name or overrides previous.
+// Error returned by action defines exit code of application
+// (see ExitCode()), so it may be *ActionError with specific code.
+//
+// This method is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func (d *Daemon) SetActionFunc(name string, action func() error) {
+	if name == "" {
+		panic("daemonigo.SetActionFunc(): name cannot be empty")
+	}
+	if action == nil {
+		panic("daemonigo.SetActionFunc(): action cannot be nil")
+	}
+	d.setActionRun(name, func([]string) error {
+		return action()
+	})
+}
+
+// Sets action with given name performed by given function.
+// Aliases, description and visibility of overridden action are kept.
+func (d *Daemon) setActionRun(name string, run func(args []string) error) {
+	action := &Action{Name: name, Run: run}
+	if prev, exist := d.actions[name]; exist {
+		action.Aliases, action.Help = prev.Aliases, prev.Help
+		action.Hidden = prev.Hidden
+	}
+	d.actions[name] = action
 }
 
 // Removes daemon action with given name.
@@ -104,5 +661,13 @@ func SetAction(name string, action func()) {
 // This function is not concurrent safe, so you must synchronize
 // its calls in case of usage in multiple goroutines.
 func RemoveAction(name string) {
-	delete(actions, name)
+	stdDaemon().RemoveAction(name)
+}
+
+// Removes daemon action with given name.
+//
+// This method is not concurrent safe, so you must synchronize
+// its calls in case of usage in multiple goroutines.
+func (d *Daemon) RemoveAction(name string) {
+	delete(d.actions, name)
 }
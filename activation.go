@@ -0,0 +1,123 @@
+package daemonigo
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Names of environment variables of systemd socket activation protocol
+// (see sd_listen_fds(3)).
+const (
+	listenPidEnvVarName     = "LISTEN_PID"
+	listenFdsEnvVarName     = "LISTEN_FDS"
+	listenFdNamesEnvVarName = "LISTEN_FDNAMES"
+)
+
+// First file descriptor passed by systemd socket activation.
+const listenFdsStart = 3
+
+// Names of listeners passed by socket activation.
+const (
+	// Name of listener passed by systemd socket activation without
+	// FileDescriptorName= setting of socket unit.
+	UnnamedListenerName = "unknown"
+
+	// Name of listener passed inetd-style as standard input of process.
+	StdinListenerName = "stdin"
+)
+
+// Takes listeners passed by systemd socket activation to current process,
+// so they can be resumed later by Listen() and ListenPacket()
+// with names set by FileDescriptorName= setting of socket unit.
+//
+// If there are no such listeners, then takes listening socket
+// passed inetd-style as standard input of process.
+func (d *Daemon) takeActivatedListeners() {
+	pid := os.Getenv(listenPidEnvVarName)
+	fds := os.Getenv(listenFdsEnvVarName)
+	names := os.Getenv(listenFdNamesEnvVarName)
+	if pid == "" && fds == "" {
+		d.takeStdinListener()
+		return
+	}
+	os.Unsetenv(listenPidEnvVarName)
+	os.Unsetenv(listenFdsEnvVarName)
+	os.Unsetenv(listenFdNamesEnvVarName)
+	if p, err := strconv.Atoi(pid); err != nil || p != os.Getpid() {
+		return
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n <= 0 {
+		return
+	}
+	var fdNames []string
+	if names != "" {
+		fdNames = strings.Split(names, ":")
+	}
+	for i := 0; i < n; i++ {
+		fd, name := listenFdsStart+i, UnnamedListenerName
+		if i < len(fdNames) && fdNames[i] != "" &&
+			!strings.ContainsAny(fdNames[i], ":,") {
+			name = fdNames[i]
+		}
+		syscall.CloseOnExec(fd)
+		d.inherit(name, os.NewFile(uintptr(fd), name))
+	}
+}
+
+// Takes listening stream socket or datagram network socket,
+// which is passed inetd-style as standard input of process.
+// Standard input itself is left untouched.
+func (d *Daemon) takeStdinListener() {
+	fd := int(os.Stdin.Fd())
+	typ, err := syscall.GetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_TYPE)
+	if err != nil {
+		return
+	}
+	switch typ {
+	case syscall.SOCK_STREAM:
+		accepting, err := syscall.GetsockoptInt(
+			fd, syscall.SOL_SOCKET, syscall.SO_ACCEPTCONN,
+		)
+		if err != nil || accepting == 0 {
+			return
+		}
+	case syscall.SOCK_DGRAM:
+		switch addr, _ := syscall.Getsockname(fd); addr.(type) {
+		case *syscall.SockaddrInet4, *syscall.SockaddrInet6:
+		default:
+			return
+		}
+	default:
+		return
+	}
+	if fd, err = syscall.Dup(fd); err != nil {
+		return
+	}
+	syscall.CloseOnExec(fd)
+	d.inherit(StdinListenerName, os.NewFile(uintptr(fd), StdinListenerName))
+}
+
+// Returns sorted names of listeners passed to process by socket activation
+// or by predecessor process on reload, which are not resumed yet
+// by Listen() or ListenPacket().
+func InheritedListeners() []string {
+	return stdDaemon().InheritedListeners()
+}
+
+// Returns sorted names of listeners passed to process by socket activation
+// or by predecessor process on reload, which are not resumed yet
+// by Listen() or ListenPacket().
+func (d *Daemon) InheritedListeners() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.inherited))
+	for name := range d.inherited {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,55 @@
+package daemonigo
+
+import (
+	"flag"
+	"os"
+)
+
+// Name of environment variable which holds name of action
+// launched daemonized process.
+func (d *Daemon) actionEnvVarName() string {
+	return d.EnvVarName + "_ACTION"
+}
+
+// Takes name of action, which launched daemonized process,
+// from environment.
+func (d *Daemon) takeLaunchAction() {
+	name := d.actionEnvVarName()
+	d.action = os.Getenv(name)
+	os.Unsetenv(name)
+}
+
+// Returns arguments to be passed to daemonized process.
+//
+// In parent process these are command line flags preceding action name
+// along with ExtraArgs, filtered by ArgsFilter. Daemonized process
+// passes its own arguments as is, when starting new daemonized process,
+// unless it runs in foreground.
+func (d *Daemon) daemonArgs() []string {
+	if d.daemonized && !d.foreground {
+		return os.Args[1:]
+	}
+	args := []string{}
+	if flag.Parsed() && len(os.Args) > flag.NArg() {
+		args = append(args, os.Args[1:len(os.Args)-flag.NArg()]...)
+	}
+	args = append(args, d.ExtraArgs...)
+	if d.ArgsFilter != nil {
+		args = d.ArgsFilter(args)
+	}
+	return args
+}
+
+// Returns name of action which launched daemonized process,
+// like "start", "restart" or "reload".
+// In parent process returns name of action being performed.
+func ActionName() string {
+	return stdDaemon().ActionName()
+}
+
+// Returns name of action which launched daemonized process,
+// like "start", "restart" or "reload".
+// In parent process returns name of action being performed.
+func (d *Daemon) ActionName() string {
+	return d.action
+}
@@ -0,0 +1,240 @@
+package daemonigo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of listener of control socket registered by Listen(),
+// so control socket is passed to new daemonized process on reload.
+const controlListenerName = "daemonigo-control"
+
+// Timeout of single request to control socket.
+const controlTimeout = 5 * time.Second
+
+// Handler of control socket command. Receives command arguments
+// and returns reply, which is printed by calling process.
+type CommandHandler func(args []string) (string, error)
+
+// Request sent to control socket.
+type controlRequest struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response of control socket.
+type controlResponse struct {
+	Reply string `json:"reply,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Returns path to control socket of daemon.
+func (d *Daemon) controlPath() string {
+	return d.pidFileSibling(".sock")
+}
+
+// Registers handler of control socket command with given name
+// or overrides previous. Must be called in daemonized process.
+// Commands "ping", "status" and "health" are built-in,
+// but can be overridden.
+func HandleCommand(name string, handler CommandHandler) {
+	stdDaemon().HandleCommand(name, handler)
+}
+
+// Registers handler of control socket command with given name
+// or overrides previous. Must be called in daemonized process.
+// Commands "ping", "status" and "health" are built-in,
+// but can be overridden.
+func (d *Daemon) HandleCommand(name string, handler CommandHandler) {
+	if name == "" {
+		panic("daemonigo.HandleCommand(): name cannot be empty")
+	}
+	if handler == nil {
+		panic("daemonigo.HandleCommand(): handler cannot be nil")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.commands == nil {
+		d.commands = make(map[string]CommandHandler)
+	}
+	d.commands[name] = handler
+}
+
+// Returns handler of control socket command with given name.
+func (d *Daemon) command(name string) CommandHandler {
+	d.mu.Lock()
+	handler := d.commands[name]
+	d.mu.Unlock()
+	if handler != nil {
+		return handler
+	}
+	switch name {
+	case "ping":
+		return func([]string) (string, error) {
+			return "pong", nil
+		}
+	case "status":
+		return d.statusCommand
+	case "health":
+		return d.healthCommand
+	}
+	return nil
+}
+
+// Built-in "status" command of control socket.
+func (d *Daemon) statusCommand([]string) (string, error) {
+	d.mu.Lock()
+	commands := make([]string, 0, len(d.commands))
+	for name := range d.commands {
+		commands = append(commands, name)
+	}
+	d.mu.Unlock()
+	sort.Strings(commands)
+	lines := []string{
+		fmt.Sprintf("PID: %d", os.Getpid()),
+		fmt.Sprintf("Launched by: %s", d.action),
+		fmt.Sprintf("Uptime: %s", time.Since(d.started).Round(time.Second)),
+	}
+	if len(commands) > 0 {
+		lines = append(lines, "Commands: "+strings.Join(commands, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Starts serving control socket in daemonized process.
+// Resumes control socket of predecessor process on reload.
+func (d *Daemon) serveControl() error {
+	if !d.Control {
+		return nil
+	}
+	path := d.controlPath()
+	d.mu.Lock()
+	_, inherited := d.inherited[controlListenerName]
+	d.mu.Unlock()
+	if !inherited {
+		// socket file can be left by crashed process,
+		// and it is safe to remove it while PID file is locked
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	l, err := d.Listen(controlListenerName, "unix", path)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf(
+					"daemonigo: failed to accept control connection, "+
+						"reason -> %s", err.Error(),
+				)
+				time.Sleep(controlTimeout / 50)
+				continue
+			}
+			go d.handleControl(conn)
+		}
+	}()
+	return nil
+}
+
+// Stops serving control socket, which has been passed
+// to new daemonized process on reload.
+func (d *Daemon) stopControl() {
+	d.mu.Lock()
+	l := d.listeners[controlListenerName]
+	d.mu.Unlock()
+	if l != nil {
+		l.Close()
+	}
+}
+
+// Handles single request to control socket.
+func (d *Daemon) handleControl(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+	var (
+		req  controlRequest
+		resp controlResponse
+	)
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		resp.Error = "bad request: " + err.Error()
+	} else if handler := d.command(req.Command); handler == nil {
+		resp.Error = fmt.Sprintf("unknown command %q", req.Command)
+	} else if reply, err := handler(req.Args); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Reply = reply
+	}
+	if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+		log.Printf(
+			"daemonigo: failed to reply to control command %q, reason -> %s",
+			req.Command, err.Error(),
+		)
+	}
+}
+
+// Calls command of running daemon via its control socket
+// and returns its reply.
+//
+// This function can also be used when writing your own daemon actions.
+func Call(command string, args ...string) (string, error) {
+	return stdDaemon().Call(command, args...)
+}
+
+// Calls command of running daemon via its control socket
+// and returns its reply.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) Call(command string, args ...string) (string, error) {
+	const errLoc = "daemonigo.Call()"
+	if !d.Control {
+		return "", fmt.Errorf(
+			"%s: control socket of %s is disabled", errLoc, d.AppName,
+		)
+	}
+	conn, err := net.DialTimeout("unix", d.controlPath(), controlTimeout)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to connect to %s, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+	err = json.NewEncoder(conn).Encode(&controlRequest{
+		Command: command, Args: args,
+	})
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to send command %q, reason -> %w",
+			errLoc, command, err,
+		)
+	}
+	var resp controlResponse
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to read reply to command %q, reason -> %w",
+			errLoc, command, err,
+		)
+	}
+	if resp.Error != "" {
+		return resp.Reply, fmt.Errorf(
+			"%s: %w %q, reason -> %s",
+			errLoc, ErrCommandFailed, command, resp.Error,
+		)
+	}
+	return resp.Reply, nil
+}
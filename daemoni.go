@@ -2,14 +2,16 @@
 package daemonigo
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -43,67 +45,464 @@ var AppPath = "./" + filepath.Base(os.Args[0])
 // Absolute or relative path from working directory to PID file.
 var PidFile = "daemon.pid"
 
-// Pointer to PID file to keep file-lock alive.
-var pidFile *os.File
+// Number of attempts to lock PID file and interval between them.
+const (
+	lockAttempts      = 5
+	lockRetryInterval = 20 * time.Millisecond
+)
+
+// Daemon describes a single daemonized application
+// with its own settings, actions and PID file.
+//
+// Several daemons can be managed from one binary,
+// each of them must have its own PID file.
+// Zero value is not usable, use New() to create a Daemon.
+type Daemon struct {
+	// Application name to daemonize.
+	// Used for printing in default daemon actions.
+	AppName string
+
+	// Path to application executable.
+	// Used only for default start/restart actions.
+	AppPath string
+
+	// Absolute or relative path from working directory to PID file.
+	PidFile string
+
+	// Short description of application, used in generated
+	// service files (see SystemdUnits() and InitScript()).
+	// If empty, AppName is used.
+	Description string
+
+	// Names of system facilities or services, which daemon depends on,
+	// used in generated service files (see SystemdUnits() and InitScript()).
+	// Facilities "network", "local_fs", "remote_fs", "syslog", "named",
+	// "time" and "portmap" are translated for each service manager,
+	// other names are used as names of services as is.
+	Dependencies []string
+
+	// Value of file mask for PID-file.
+	PidFileMask os.FileMode
+
+	// Path to daemon working directory.
+	// If not set, the current user directory will be used.
+	WorkDir string
+
+	// Value of umask for daemonized process.
+	Umask int
+
+	// Name of environment variable used to distinguish
+	// parent and daemonized processes.
+	EnvVarName string
+
+	// Value of environment variable used to distinguish
+	// parent and daemonized processes.
+	EnvVarValue string
+
+	// If set, daemon is considered started only after daemonized process
+	// calls Ready(). Otherwise, daemon is considered started if it keeps
+	// running during start timeout.
+	RequireReady bool
+
+	// Timeout of starting daemon in default actions.
+	StartTimeout time.Duration
+
+	// If set, default start and restart actions consider daemon started
+	// only after all its health checks pass (see HandleHealthCheck())
+	// within another StartTimeout, otherwise daemon is stopped.
+	// Requires Control to be set.
+	RequireHealthy bool
+
+	// Signal which makes daemonized process to reload itself
+	// (see Reload()), usually syscall.SIGHUP. If nil, reloading is disabled.
+	//
+	// Replaced process keeps running until it exits by itself,
+	// so set it only if daemon finishes its work on Replaced()
+	// or uses RegisterShutdown() or Hooks.OnShutdown.
+	ReloadSignal os.Signal
+
+	// Timeout of reloading daemon in default actions.
+	// Must be greater than StartTimeout.
+	ReloadTimeout time.Duration
+
+	// Describes how daemon process is stopped by Stop().
+	// By default SIGINT, SIGTERM and finally SIGKILL are sent.
+	StopPolicy StopPolicy
+
+	// Timeout of the whole shutdown of daemonized process
+	// (see Shutdown()). Zero value means waiting without limit.
+	ShutdownTimeout time.Duration
+
+	// Path to file, which standard output of daemonized process
+	// is redirected to. If not set, standard output is discarded.
+	StdoutFile string
+
+	// Path to file, which standard error of daemonized process
+	// is redirected to. If not set, standard error is discarded.
+	// May be the same as StdoutFile.
+	StderrFile string
+
+	// Value of file mask for log files.
+	LogFileMask os.FileMode
+
+	// Rotation of log files performed by daemonized process.
+	LogRotation LogRotation
+
+	// Signal which makes daemonized process to reopen its log files
+	// (see ReopenLogs()). If nil, reopening on signal is disabled.
+	ReopenSignal os.Signal
+
+	// If set, daemonized process stays resident as a supervisor
+	// and runs application in a worker process, which is restarted
+	// on abnormal exit according to RestartPolicy.
+	//
+	// Reload in supervised mode restarts worker process,
+	// so it is not zero-downtime. SIGKILL stop step kills the whole
+	// process group of supervisor, so worker process is killed too.
+	Supervise bool
+
+	// Describes how worker process is restarted in supervised mode.
+	RestartPolicy RestartPolicy
+
+	// Name or numeric ID of user to run daemonized process as
+	// after DropPrivileges() call.
+	User string
+
+	// Name or numeric ID of group to run daemonized process as
+	// after DropPrivileges() call. If not set, primary group of User
+	// is used.
+	Group string
+
+	// Names or numeric IDs of supplementary groups of daemonized process
+	// after DropPrivileges() call.
+	SupplementaryGroups []string
+
+	// Additional arguments passed to daemonized process
+	// after command line flags of parent process.
+	ExtraArgs []string
+
+	// Filters arguments passed to daemonized process. Receives command
+	// line flags of parent process (without action name) followed by
+	// ExtraArgs, and returns arguments to be passed.
+	ArgsFilter func(args []string) []string
+
+	// Hooks of daemon lifecycle executed by default actions
+	// and daemonized process.
+	Hooks Hooks
+
+	// Sockets passed to daemon by systemd socket activation,
+	// used for generating socket units (see SystemdUnits()).
+	Sockets []Socket
+
+	// If set, daemonized process serves Unix domain control socket
+	// next to PID file, so commands registered by HandleCommand()
+	// can be called by Call().
+	Control bool
+
+	// Daemon actions by their names, see RegisterAction().
+	actions map[string]*Action
+
+	// Name of action being performed in parent process,
+	// or name of action launched daemonized process.
+	action string
+
+	// Indicates that current process is daemonized.
+	daemonized bool
+
+	// Indicates that daemonized process runs in foreground.
+	foreground bool
+
+	// Pointer to PID file to keep file-lock alive.
+	pidFile *os.File
+
+	// Readiness notification pipes of started commands.
+	readyPipes map[*exec.Cmd]*readyPipe
+
+	// Inherited readiness notification pipe of daemonized process.
+	readyFile *os.File
+
+	// Listeners registered by Listen() and ListenPacket().
+	listeners map[string]fileConn
+
+	// Listeners inherited from predecessor process on reload
+	// or passed by socket activation.
+	inherited map[string]*os.File
+
+	// Closed when daemonized process is replaced by its successor.
+	replaced chan struct{}
+
+	// Serializes reloads of daemonized process.
+	reloadMu sync.Mutex
+
+	// Serializes reopening and rotation of log files.
+	logsMu sync.Mutex
+
+	// Worker process run by supervisor.
+	worker *os.Process
+
+	// Handlers of control socket commands.
+	commands map[string]CommandHandler
+
+	// Health checks registered by HandleHealthCheck().
+	healthChecks map[string]HealthCheck
+
+	// Signal handlers registered by HandleSignal()
+	// and channel of signals routed to them.
+	signalHandlers map[os.Signal]SignalHandler
+	signals        chan os.Signal
+
+	// Steps of shutdown registered by RegisterShutdown().
+	shutdownSteps []shutdownStep
+
+	// Ensures that shutdown is performed only once.
+	shutdownOnce sync.Once
+
+	// Closed when shutdown is finished.
+	shutdownDone chan struct{}
+
+	// Error of shutdown and number of its waiters.
+	shutdownErr     error
+	shutdownWaiters int
+
+	// Time when daemonized process has been initialized.
+	started time.Time
+
+	// Guards fields shared between goroutines.
+	mu sync.Mutex
+}
+
+// Creates new Daemon with given application name and default settings.
+// PID file is named after application name.
+func New(appName string) *Daemon {
+	d := &Daemon{
+		AppName:       appName,
+		AppPath:       "./" + filepath.Base(os.Args[0]),
+		PidFile:       appName + ".pid",
+		PidFileMask:   0644,
+		Umask:         027,
+		EnvVarName:    "_DAEMONIGO",
+		EnvVarValue:   "1",
+		StartTimeout:  time.Second,
+		ReloadTimeout: 10 * time.Second,
+		StopPolicy: StopPolicy{
+			Steps: []StopStep{
+				{Signal: os.Interrupt, Timeout: 10 * time.Second},
+				{Signal: syscall.SIGTERM, Timeout: 10 * time.Second},
+				{Signal: syscall.SIGKILL, Timeout: 5 * time.Second},
+			},
+			KillTimeout: 5 * time.Second,
+		},
+		ShutdownTimeout: 10 * time.Second,
+		LogFileMask:     0640,
+		ReopenSignal:    syscall.SIGUSR1,
+		RestartPolicy: RestartPolicy{
+			Delay:       time.Second,
+			MaxDelay:    time.Minute,
+			MaxRestarts: 5,
+			Window:      time.Minute,
+		},
+	}
+	d.actions = make(map[string]*Action)
+	for _, action := range defaultActions(d) {
+		d.actions[action.Name] = action
+	}
+	return d
+}
+
+// Daemon instance used by package-level functions.
+var std = New("daemon")
+
+// Synchronizes package-level settings with default Daemon instance
+// and returns it. Package-level functions are thin wrappers over it.
+//
+// Settings are written only when package-level variables have changed,
+// so goroutines of daemonized process can read them without races.
+func stdDaemon() *Daemon {
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	if std.AppName != AppName {
+		std.AppName = AppName
+	}
+	if std.AppPath != AppPath {
+		std.AppPath = AppPath
+	}
+	if std.PidFile != PidFile {
+		std.PidFile = PidFile
+	}
+	if std.PidFileMask != PidFileMask {
+		std.PidFileMask = PidFileMask
+	}
+	if std.WorkDir != WorkDir {
+		std.WorkDir = WorkDir
+	}
+	if std.Umask != Umask {
+		std.Umask = Umask
+	}
+	if std.EnvVarName != EnvVarName {
+		std.EnvVarName = EnvVarName
+	}
+	if std.EnvVarValue != EnvVarValue {
+		std.EnvVarValue = EnvVarValue
+	}
+	return std
+}
+
+// Returns Daemon instance used by package-level functions.
+// Its settings, which have package-level variables,
+// are overridden by values of these variables.
+func Default() *Daemon {
+	return stdDaemon()
+}
 
 // This function wraps application with daemonization.
 // Returns isDaemon value to distinguish parent and daemonized processes.
+//
+// In parent process returns error of performed action,
+// which can be turned into exit code with ExitCode().
+// Default "run" action initializes current process as daemonized one
+// running in foreground, so it returns isDaemon as well.
 func Daemonize() (isDaemon bool, err error) {
+	return stdDaemon().Daemonize()
+}
+
+// This method wraps application with daemonization.
+// Returns isDaemon value to distinguish parent and daemonized processes.
+//
+// In parent process returns error of performed action,
+// which can be turned into exit code with ExitCode().
+// Default "run" action initializes current process as daemonized one
+// running in foreground, so it returns isDaemon as well.
+func (d *Daemon) Daemonize() (isDaemon bool, err error) {
 	const errLoc = "daemonigo.Daemonize()"
-	isDaemon = os.Getenv(EnvVarName) == EnvVarValue
-	if WorkDir != "" {
-		if err = os.Chdir(WorkDir); err != nil {
+	isDaemon = os.Getenv(d.EnvVarName) == d.EnvVarValue
+	if d.WorkDir != "" {
+		if err = os.Chdir(d.WorkDir); err != nil {
 			err = fmt.Errorf(
-				"%s: changing working directory failed, reason -> %s",
-				errLoc, err.Error(),
+				"%s: changing working directory failed, reason -> %w",
+				errLoc, err,
 			)
 			return
 		}
 	}
 	if isDaemon {
-		syscall.Umask(int(Umask))
-		if _, err = syscall.Setsid(); err != nil {
-			err = fmt.Errorf(
-				"%s: setsid failed, reason -> %s", errLoc, err.Error(),
-			)
-			return
-		}
-		if pidFile, err = lockPidFile(); err != nil {
-			err = fmt.Errorf(
-				"%s: locking PID file failed, reason -> %s",
-				errLoc, err.Error(),
-			)
-		}
+		err = d.initDaemon()
 	} else {
 		flag.Usage = func() {
-			arr := make([]string, 0, len(actions))
-			for k, _ := range actions {
-				arr = append(arr, k)
-			}
-			fmt.Fprintf(os.Stderr, "Usage: %s {%s}\n",
-				os.Args[0], strings.Join(arr, "|"),
-			)
-			flag.PrintDefaults()
+			d.printUsage(os.Stderr)
 		}
 		if !flag.Parsed() {
 			flag.Parse()
 		}
-		action, exist := actions[flag.Arg(0)]
-		if exist {
-			action()
+		d.takeActivatedListeners()
+		name := flag.Arg(0)
+		if name == "start" && d.foregroundForced() {
+			if d.LookupAction(foregroundAction) != nil {
+				name = foregroundAction
+			}
+		}
+		if action := d.LookupAction(name); action != nil {
+			d.action = action.Name
+			err = d.perform(action, flag.Args()[1:])
+			isDaemon = d.daemonized
 		} else {
 			flag.Usage()
+			err = newActionError(ExitInvalidArgs, fmt.Errorf(
+				"%s: unknown action %q", errLoc, flag.Arg(0),
+			))
 		}
 	}
 	return
 }
 
-// Locks PID file with a file lock.
+// Initializes daemonized process: detaches it from parent session,
+// locks PID file and starts handling of signals.
+// Process running in foreground is not detached.
+//
+// In supervised mode it never returns in supervisor process,
+// which exits after supervising is finished.
+func (d *Daemon) initDaemon() (err error) {
+	const errLoc = "daemonigo.Daemonize()"
+	d.daemonized = true
+	d.started = time.Now()
+	if !d.foreground {
+		d.takeLaunchAction()
+		d.takeInheritedReadyPipe()
+	}
+	defer func() {
+		if err != nil {
+			d.NotifyFailed(err)
+		}
+	}()
+	syscall.Umask(int(d.Umask))
+	if !d.foreground {
+		d.takeInheritedListeners()
+		d.takeActivatedListeners()
+	}
+	if d.takeWorkerMark() {
+		// supervisor holds PID file and rotates logs itself
+		d.watchLogs(false)
+		if d.Hooks.OnShutdown != nil {
+			d.watchShutdown()
+		}
+		if err = d.serveControl(); err != nil {
+			return fmt.Errorf(
+				"%s: serving control socket failed, reason -> %w",
+				errLoc, err,
+			)
+		}
+		return
+	}
+	if !d.foreground {
+		if _, err = syscall.Setsid(); err != nil {
+			return fmt.Errorf(
+				"%s: setsid failed, reason -> %w", errLoc, err,
+			)
+		}
+	}
+	// PID of process, which has inherited PID file on reload, is written
+	// by its predecessor after accepting its readiness (see Reload())
+	if d.pidFile = d.takeInheritedPidFile(); d.pidFile == nil {
+		if d.pidFile, err = d.lockPidFile(); err != nil {
+			return fmt.Errorf(
+				"%s: locking PID file failed, reason -> %w",
+				errLoc, err,
+			)
+		}
+	}
+	if d.Supervise {
+		if !d.foreground {
+			d.watchLogs(true)
+		}
+		if err = d.supervise(); err != nil {
+			log.Println(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if err = d.serveControl(); err != nil {
+		return fmt.Errorf(
+			"%s: serving control socket failed, reason -> %w", errLoc, err,
+		)
+	}
+	if !d.foreground {
+		d.watchReload()
+		d.watchLogs(true)
+	}
+	if d.Hooks.OnShutdown != nil {
+		d.watchShutdown()
+	}
+	return
+}
+
+// Locks PID file with a file lock without blocking.
+// Returns ErrAlreadyRunning if PID file is locked by another process.
+// PID file content is replaced only after the lock is acquired,
+// so running daemon never loses its PID.
 // Keeps PID file open until applications exits.
-func lockPidFile() (pidFile *os.File, err error) {
+func (d *Daemon) lockPidFile() (pidFile *os.File, err error) {
 	var file *os.File
 	file, err = os.OpenFile(
-		PidFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, PidFileMask,
+		d.PidFile, os.O_WRONLY|os.O_CREATE, d.PidFileMask,
 	)
 	if err != nil {
 		return
@@ -115,47 +514,86 @@ func lockPidFile() (pidFile *os.File, err error) {
 		}
 	}()
 
-	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		return
+	for attempt := 1; ; attempt++ {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err != syscall.EWOULDBLOCK {
+			break
+		}
+		// Status() holds the lock for a moment while checking it,
+		// so give it a chance to release the lock
+		if attempt == lockAttempts {
+			return nil, ErrAlreadyRunning
+		}
+		time.Sleep(lockRetryInterval)
 	}
-	var fileLen int
-	fileLen, err = fmt.Fprint(file, os.Getpid())
 	if err != nil {
 		return
 	}
-	if err = file.Truncate(int64(fileLen)); err != nil {
+	if err = d.writePid(file, os.Getpid()); err != nil {
 		return
 	}
 
 	return file, err
 }
 
+// Writes given PID along with identity of its process
+// into locked PID file replacing its previous content.
+func (d *Daemon) writePid(file *os.File, pid int) (err error) {
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	var fileLen int
+	if fileLen, err = fmt.Fprint(file, pidFileContent(pid)); err != nil {
+		return
+	}
+	return file.Truncate(int64(fileLen))
+}
+
 // Unlocks PID file (locked by current daemonized process) and closes this file.
 //
 // This function can be useful for graceful restarts or other
 // untrivial scenarios, but usually there is no need to use it.
 func UnlockPidFile() {
-	if pidFile != nil {
-		syscall.Flock(int(pidFile.Fd()), syscall.LOCK_UN)
-		pidFile.Close()
+	stdDaemon().UnlockPidFile()
+}
+
+// Unlocks PID file (locked by current daemonized process) and closes this file.
+//
+// This method can be useful for graceful restarts or other
+// untrivial scenarios, but usually there is no need to use it.
+func (d *Daemon) UnlockPidFile() {
+	if d.pidFile != nil {
+		syscall.Flock(int(d.pidFile.Fd()), syscall.LOCK_UN)
+		d.pidFile.Close()
+		d.pidFile = nil
 	}
 }
 
 // Checks status of daemonized process.
 // Can be used in daemon actions to perate with daemonized process.
 func Status() (isRunning bool, pr *os.Process, e error) {
+	return stdDaemon().Status()
+}
+
+// Checks status of daemonized process.
+// Can be used in daemon actions to perate with daemonized process.
+//
+// Verifies that process, which holds PID file, is the daemon itself,
+// and returns error matching ErrUnexpectedProcess if it is not,
+// so no signals are sent to a stranger.
+func (d *Daemon) Status() (isRunning bool, pr *os.Process, e error) {
 	const errLoc = "daemonigo.Status()"
 	var (
 		err  error
 		file *os.File
 	)
 
-	file, err = os.Open(PidFile)
+	file, err = os.Open(d.PidFile)
 	if err != nil {
 		if !os.IsNotExist(err) {
 			e = fmt.Errorf(
-				"%s: could not open PID file, reason -> %s",
-				errLoc, err.Error(),
+				"%s: could not open PID file, reason -> %w",
+				errLoc, err,
 			)
 		}
 		return
@@ -169,35 +607,44 @@ func Status() (isRunning bool, pr *os.Process, e error) {
 			syscall.Flock(fd, syscall.LOCK_UN)
 		} else {
 			e = fmt.Errorf(
-				"%s: PID file locking attempt failed, reason -> %s",
-				errLoc, err.Error(),
+				"%s: PID file locking attempt failed, reason -> %w",
+				errLoc, err,
 			)
 		}
 		return
 	}
 
 	isRunning = true
-	var n, pid int
-	content := make([]byte, 128)
-	n, err = file.Read(content)
-	if err != nil && err != io.EOF {
+	var (
+		pid int
+		id  procIdentity
+	)
+	// PID file is read whole, as executable path in it has no length limit
+	content, err := io.ReadAll(file)
+	if err != nil {
 		e = fmt.Errorf(
-			"%s: could not read from PID file, reason -> %s",
-			errLoc, err.Error(),
+			"%s: could not read from PID file, reason -> %w",
+			errLoc, err,
 		)
 		return
 	}
-	pid, err = strconv.Atoi(string(content[:n]))
-	if n < 1 || err != nil {
+	pid, id, err = parsePidFile(string(content))
+	if len(content) < 1 || err != nil {
 		e = fmt.Errorf(
-			"%s: bad PID format, PID file is possibly corrupted", errLoc,
+			"%s: bad PID format, reason -> %w", errLoc, ErrPidFileCorrupt,
+		)
+		return
+	}
+	if err = d.verifyProcess(pid, id); err != nil {
+		e = fmt.Errorf(
+			"%s: %w, reason -> %w", errLoc, ErrUnexpectedProcess, err,
 		)
 		return
 	}
 	pr, err = os.FindProcess(pid)
 	if err != nil {
 		e = fmt.Errorf(
-			"%s: cannot find process by PID, reason -> %s", errLoc, err.Error(),
+			"%s: cannot find process by PID, reason -> %w", errLoc, err,
 		)
 	}
 
@@ -205,91 +652,178 @@ func Status() (isRunning bool, pr *os.Process, e error) {
 }
 
 // Prepares and returns command for starting daemonized process.
+// Command line flags of current process are passed to daemonized process
+// (see ExtraArgs and ArgsFilter).
 //
 // This function can also be used when writing your own daemon actions.
 func StartCommand() (*exec.Cmd, error) {
+	return stdDaemon().StartCommand()
+}
+
+// Prepares and returns command for starting daemonized process.
+// Command line flags of current process are passed to daemonized process
+// (see ExtraArgs and ArgsFilter).
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) StartCommand() (*exec.Cmd, error) {
 	const errLoc = "daemonigo.StartCommand()"
-	path, err := filepath.Abs(AppPath)
+	path, err := filepath.Abs(d.AppPath)
 	if err != nil {
 		return nil, fmt.Errorf(
-			"%s: failed to resolve absolute path of %s, reason -> %s",
-			errLoc, AppName, err.Error(),
+			"%s: failed to resolve absolute path of %s, reason -> %w",
+			errLoc, d.AppName, err,
 		)
 	}
-	cmd := exec.Command(path)
-	cmd.Env = append(
-		os.Environ(), fmt.Sprintf("%s=%s", EnvVarName, EnvVarValue),
+	cmd := exec.Command(path, d.daemonArgs()...)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", d.EnvVarName, d.EnvVarValue),
+		fmt.Sprintf("%s=%s", d.actionEnvVarName(), d.action),
 	)
+	logs, err := d.attachLogFiles(cmd)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to open log files, reason -> %w", errLoc, err,
+		)
+	}
+	names, inherited, err := d.dupInherited()
+	if err != nil {
+		for _, file := range logs {
+			file.Close()
+		}
+		return nil, fmt.Errorf("%s: %w", errLoc, err)
+	}
+	d.passListeners(cmd, names, inherited)
+	passed := append(logs, inherited...)
+	if err = d.attachReadyPipe(cmd, passed); err != nil {
+		for _, file := range passed {
+			file.Close()
+		}
+		return nil, fmt.Errorf(
+			"%s: failed to create readiness notification pipe, reason -> %w",
+			errLoc, err,
+		)
+	}
 	return cmd, nil
 }
 
-// Starts daemon process and waits timeout number of seconds.
+// Starts daemon process and waits timeout number of seconds
+// for its readiness notification (see Ready() and NotifyFailed()).
 // If daemonized process keeps running after timeout seconds passed
-// then process seems to be successfully started.
+// then process seems to be successfully started, unless RequireReady is set.
 //
-// This function can also be used when writing your own daemon actions.
+// Deprecated: use StartContext(), which accepts any timeout
+// and can be aborted.
 func Start(timeout uint8) (e error) {
-	const errLoc = "daemonigo.Start()"
-	cmd, err := StartCommand()
+	return stdDaemon().Start(timeout)
+}
+
+// Starts daemon process and waits timeout number of seconds
+// for its readiness notification (see Ready() and NotifyFailed()).
+// If daemonized process keeps running after timeout seconds passed
+// then process seems to be successfully started, unless RequireReady is set.
+//
+// Deprecated: use StartContext(), which accepts any timeout
+// and can be aborted.
+func (d *Daemon) Start(timeout uint8) (e error) {
+	return d.StartContext(
+		context.Background(), time.Duration(timeout)*time.Second,
+	)
+}
+
+// Time to wait for daemon process to exit after failed start.
+const abortTimeout = time.Second
+
+// Starts daemon process and waits given timeout for its readiness
+// notification (see Ready() and NotifyFailed()).
+// If daemonized process keeps running after timeout passed
+// then process seems to be successfully started, unless RequireReady is set.
+// Starting is aborted when given context is done.
+// Started process is killed if it fails to notify its readiness
+// or starting is aborted.
+//
+// This function can also be used when writing your own daemon actions.
+func StartContext(ctx context.Context, timeout time.Duration) error {
+	return stdDaemon().StartContext(ctx, timeout)
+}
+
+// Starts daemon process and waits given timeout for its readiness
+// notification (see Ready() and NotifyFailed()).
+// If daemonized process keeps running after timeout passed
+// then process seems to be successfully started, unless RequireReady is set.
+// Starting is aborted when given context is done.
+// Started process is killed if it fails to notify its readiness
+// or starting is aborted.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) StartContext(
+	ctx context.Context, timeout time.Duration,
+) error {
+	const errLoc = "daemonigo.StartContext()"
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf(
+			"%s: starting of %s aborted, reason -> %w", errLoc, d.AppName, err,
+		)
+	}
+	cmd, err := d.StartCommand()
 	if err != nil {
 		return fmt.Errorf(
-			"%s: failed to create daemon start command, reason -> %s",
-			errLoc, err.Error(),
+			"%s: failed to create daemon start command, reason -> %w",
+			errLoc, err,
 		)
 	}
 	if err = cmd.Start(); err != nil {
+		d.takeReadyPipe(cmd).close()
 		return fmt.Errorf(
-			"%s: failed to start %s, reason -> %s",
-			errLoc, AppName, err.Error(),
+			"%s: failed to start %s, reason -> %w",
+			errLoc, d.AppName, err,
 		)
 	}
-	select {
-	case <-func() chan bool {
-		ch := make(chan bool)
-		go func() {
-			if err := cmd.Wait(); err != nil {
-				e = fmt.Errorf(
-					"%s: %s running failed, reason -> %s",
-					errLoc, AppName, err.Error(),
-				)
-			} else {
-				e = fmt.Errorf(
-					"%s: %s stopped and not running", errLoc, AppName,
-				)
+	if err = d.WaitReadyContext(ctx, cmd, timeout); err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrStartTimeout) ||
+			errors.Is(err, ErrStartFailed) {
+			// daemon must not be left half-started
+			cmd.Process.Kill()
+			stopped, _ := d.waitStopped(context.Background(), abortTimeout)
+			if stopped {
+				d.clearPidFile()
 			}
-			ch <- true
-		}()
-		return ch
-	}():
-	case <-time.After(time.Duration(timeout) * time.Second):
+		}
+		return err
 	}
-	return
+	return nil
 }
 
-// Stops daemon process.
-// Sends signal os.Interrupt to daemonized process.
+// Stops daemon process if it is running (see StopContext())
+// and starts it again (see StartContext()) waiting given timeout
+// for its readiness notification.
+// Restarting is aborted when given context is done.
 //
 // This function can also be used when writing your own daemon actions.
-func Stop(process *os.Process) (e error) {
-	const errLoc = "daemonigo.Stop()"
-	if err := process.Signal(os.Interrupt); err != nil {
-		e = fmt.Errorf(
-			"%s: failed to send interrupt signal to %s, reason -> %s",
-			errLoc, AppName, err.Error(),
-		)
-		return
+func RestartContext(ctx context.Context, timeout time.Duration) error {
+	return stdDaemon().RestartContext(ctx, timeout)
+}
+
+// Stops daemon process if it is running (see StopContext())
+// and starts it again (see StartContext()) waiting given timeout
+// for its readiness notification.
+// Restarting is aborted when given context is done.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) RestartContext(
+	ctx context.Context, timeout time.Duration,
+) error {
+	const errLoc = "daemonigo.RestartContext()"
+	isRunning, process, err := d.Status()
+	if err != nil {
+		return fmt.Errorf("%s: %w", errLoc, err)
 	}
-	for {
-		time.Sleep(200 * time.Millisecond)
-		switch isRunning, _, err := Status(); {
-		case err != nil:
-			e = fmt.Errorf(
-				"%s: checking status of %s failed, reason -> %s",
-				errLoc, AppName, err.Error(),
-			)
-			return
-		case !isRunning:
-			return
+	if isRunning {
+		if err = d.StopContext(ctx, process); err != nil {
+			return fmt.Errorf("%s: %w", errLoc, err)
 		}
 	}
+	if err = d.StartContext(ctx, timeout); err != nil {
+		return fmt.Errorf("%s: %w", errLoc, err)
+	}
+	return nil
 }
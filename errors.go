@@ -0,0 +1,89 @@
+package daemonigo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Errors returned by daemonigo functions.
+// They are wrapped with details, so must be checked with errors.Is().
+var (
+	// Daemon is not running while it is expected to be.
+	ErrNotRunning = errors.New("daemon is not running")
+
+	// Daemon is already running while it is expected not to be.
+	ErrAlreadyRunning = errors.New("daemon is already running")
+
+	// PID file is locked, but its content cannot be parsed.
+	ErrPidFileCorrupt = errors.New("PID file is corrupted")
+
+	// PID file is locked, but PID written in it belongs to
+	// another process, than the one which has written it.
+	ErrUnexpectedProcess = errors.New("lock held by unexpected process")
+
+	// Daemonized process has not notified its readiness in time.
+	ErrStartTimeout = errors.New("daemon start timed out")
+
+	// Daemonized process has exited before it is considered started.
+	// Returned errors are of *ExitError type, which holds exit code.
+	ErrExitedDuringStart = errors.New("daemon exited during start")
+
+	// Daemonized process has notified its failure with NotifyFailed().
+	ErrStartFailed = errors.New("daemon failed to start")
+
+	// Daemon process keeps running after all steps of StopPolicy.
+	ErrStopTimeout = errors.New("daemon stop timed out")
+
+	// Command called via control socket has returned error.
+	ErrCommandFailed = errors.New("control command failed")
+
+	// Some of health checks of daemon have failed.
+	ErrUnhealthy = errors.New("daemon is unhealthy")
+
+	// Shutdown of daemonized process has not finished in ShutdownTimeout.
+	ErrShutdownTimeout = errors.New("daemon shutdown timed out")
+
+	// Some of shutdown functions of daemonized process have failed.
+	ErrShutdownFailed = errors.New("daemon shutdown failed")
+)
+
+// Error of daemonized process, which has exited during start.
+// Matches ErrExitedDuringStart with errors.Is().
+type ExitError struct {
+	// Exit code of daemonized process,
+	// or -1 if it has been terminated by signal.
+	ExitCode int
+
+	// Underlying error of waiting for daemonized process, if any.
+	Err error
+}
+
+// Creates ExitError from result of waiting for exited process.
+func newExitError(err error) *ExitError {
+	e := &ExitError{Err: err}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		e.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		e.ExitCode = -1
+	}
+	return e
+}
+
+// Returns text representation of error.
+func (e *ExitError) Error() string {
+	if e.ExitCode < 0 && e.Err != nil {
+		return fmt.Sprintf("%s, reason -> %s", ErrExitedDuringStart, e.Err)
+	}
+	return fmt.Sprintf("%s with exit code %d", ErrExitedDuringStart, e.ExitCode)
+}
+
+// Returns underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Reports whether error matches ErrExitedDuringStart.
+func (e *ExitError) Is(target error) bool {
+	return target == ErrExitedDuringStart
+}
@@ -4,7 +4,7 @@
 // Overview
 //
 // Simple  HTTP server that runs on 8889 port and says "Hello, World!".
-// Overrides "start" action of daemonigo package to ensure that server
+// Uses readiness notification of daemonigo package to ensure that server
 // really started successfully.
 //
 //
@@ -17,8 +17,8 @@
 // server or other complicated tasks, so waiting 1 second is not enough
 // to be ensured that application started successfully and everything is fine.
 // The trick is simple: after everything was initialized successfully in
-// daemonized process we send some signal to parent process that
-// everything is OK.
+// daemonized process we call daemon.Ready() to notify parent process that
+// everything is OK, or daemon.NotifyFailed() to pass the error to it.
 //
 //
 // Usage
@@ -36,8 +36,6 @@ import (
 	"log"
 	"net"
 	"net/http"
-	"os"
-	"syscall"
 	"time"
 )
 
@@ -60,6 +58,7 @@ func main() {
 	})
 	listener, err := net.Listen("tcp", ":8889")
 	if err != nil {
+		daemon.NotifyFailed(err)
 		log.Fatalf(
 			"main(): failed to listen on port :8889, reason -> %s", err.Error(),
 		)
@@ -79,7 +78,7 @@ func main() {
 	time.Sleep(time.Second)
 
 	// Notifying parent process that we have started successfully.
-	if err := syscall.Kill(os.Getppid(), syscall.SIGUSR1); err != nil {
+	if err := daemon.Ready(); err != nil {
 		log.Fatalf(
 			"main(): notifying parent proccess failed, reason -> %s",
 			err.Error(),
@@ -0,0 +1,108 @@
+package daemonigo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes of actions, as defined by LSB for init scripts.
+const (
+	// Action has succeeded.
+	ExitOK = 0
+	// Generic or unspecified error.
+	ExitFailure = 1
+	// Invalid or excess arguments, including unknown action.
+	ExitInvalidArgs = 2
+	// Action is not implemented or not configured for daemon.
+	ExitUnimplemented = 3
+	// User has insufficient privileges.
+	ExitNoPrivilege = 4
+	// Daemon is not running, while action requires it to be.
+	ExitNotRunning = 7
+)
+
+// Exit codes of "status" action, as defined by LSB for init scripts.
+const (
+	// Daemon is running.
+	ExitStatusRunning = 0
+	// Daemon is not running, but its PID file exists.
+	ExitStatusDead = 1
+	// Daemon is not running.
+	ExitStatusNotRunning = 3
+	// Status of daemon is unknown.
+	ExitStatusUnknown = 4
+)
+
+// Error of daemon action, which holds exit code of application.
+type ActionError struct {
+	// Exit code of application, see ExitOK and ExitStatusRunning constants.
+	Code int
+
+	// Underlying error of action.
+	Err error
+}
+
+// Creates ActionError with given exit code.
+func newActionError(code int, err error) *ActionError {
+	return &ActionError{Code: code, Err: err}
+}
+
+// Returns text representation of error.
+func (e *ActionError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("action failed with exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Returns underlying error.
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// Returns exit code of application for given error returned by
+// Daemonize() in parent process.
+//
+// Returns ExitOK for nil error, code of ActionError if error wraps it,
+// ExitNoPrivilege for permission errors and ExitFailure otherwise.
+func ExitCode(err error) int {
+	var actionErr *ActionError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &actionErr):
+		return actionErr.Code
+	case errors.Is(err, os.ErrPermission):
+		return ExitNoPrivilege
+	default:
+		return ExitFailure
+	}
+}
+
+// This function wraps application with daemonization
+// like Daemonize() does, but exits parent process with exit code
+// of performed action (see ExitCode()).
+//
+// Returns only in daemonized process, which is exited with printing
+// error if its initialization fails.
+func Main() {
+	stdDaemon().Main()
+}
+
+// This method wraps application with daemonization
+// like Daemonize() does, but exits parent process with exit code
+// of performed action (see ExitCode()).
+//
+// Returns only in daemonized process, which is exited with printing
+// error if its initialization fails.
+func (d *Daemon) Main() {
+	isDaemon, err := d.Daemonize()
+	if !isDaemon {
+		os.Exit(ExitCode(err))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(ExitFailure)
+	}
+}
@@ -0,0 +1,52 @@
+package daemonigo
+
+import (
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// Name of action running daemon in foreground.
+const foregroundAction = "run"
+
+// Name of environment variable which forces default "start" and "restart"
+// actions to run daemon in foreground, like "DAEMONIGO_FOREGROUND".
+func (d *Daemon) foregroundEnvVarName() string {
+	return strings.TrimLeft(d.EnvVarName, "_") + "_FOREGROUND"
+}
+
+// Reports whether running daemon in foreground is forced by environment.
+func (d *Daemon) foregroundForced() bool {
+	forced, err := strconv.ParseBool(os.Getenv(d.foregroundEnvVarName()))
+	return err == nil && forced
+}
+
+// Turns current process into daemonized one without starting
+// a new process, so Daemonize() returns isDaemon in it.
+//
+// Process keeps its session, standard output and standard error,
+// so it can be run by container runtime or service manager.
+// As process cannot be replaced in foreground, reloading is not
+// performed and ReloadSignal with ReopenSignal are ignored.
+func (d *Daemon) runForeground() error {
+	d.foreground = true
+	for _, sig := range []os.Signal{d.ReloadSignal, d.ReopenSignal} {
+		if sig != nil {
+			signal.Ignore(sig)
+		}
+	}
+	return d.initDaemon()
+}
+
+// Reports whether daemonized process runs in foreground
+// (see default "run" action).
+func Foreground() bool {
+	return stdDaemon().Foreground()
+}
+
+// Reports whether daemonized process runs in foreground
+// (see default "run" action).
+func (d *Daemon) Foreground() bool {
+	return d.foreground
+}
@@ -0,0 +1,193 @@
+package daemonigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timeout of single health check, after which it is considered failed.
+const healthCheckTimeout = 3 * time.Second
+
+// Interval of checking health of starting daemon.
+const healthPollInterval = 200 * time.Millisecond
+
+// Health check of daemonized process.
+// Returns error if daemon is not healthy.
+type HealthCheck func() error
+
+// Result of single health check.
+type HealthResult struct {
+	// Name of health check.
+	Name string `json:"name"`
+
+	// Reports whether health check has passed.
+	Healthy bool `json:"healthy"`
+
+	// Error returned by failed health check.
+	Error string `json:"error,omitempty"`
+}
+
+// Results of all health checks of daemon.
+type HealthReport struct {
+	// Reports whether all health checks have passed.
+	Healthy bool `json:"healthy"`
+
+	// Results of health checks sorted by name.
+	Checks []HealthResult `json:"checks"`
+}
+
+// Returns error describing failed health checks of report,
+// or nil if all of them have passed.
+func (r *HealthReport) err() error {
+	if r.Healthy {
+		return nil
+	}
+	var failed []string
+	for _, check := range r.Checks {
+		if !check.Healthy {
+			failed = append(failed, check.Name+": "+check.Error)
+		}
+	}
+	return fmt.Errorf(
+		"%w, reason -> %s", ErrUnhealthy, strings.Join(failed, "; "),
+	)
+}
+
+// Registers health check with given name or overrides previous.
+// Must be called in daemonized process.
+//
+// Health checks are run on request of "health" action or Health()
+// via control socket, so Control must be set.
+func HandleHealthCheck(name string, check HealthCheck) {
+	stdDaemon().HandleHealthCheck(name, check)
+}
+
+// Registers health check with given name or overrides previous.
+// Must be called in daemonized process.
+//
+// Health checks are run on request of "health" action or Health()
+// via control socket, so Control must be set.
+func (d *Daemon) HandleHealthCheck(name string, check HealthCheck) {
+	if name == "" {
+		panic("daemonigo.HandleHealthCheck(): name cannot be empty")
+	}
+	if check == nil {
+		panic("daemonigo.HandleHealthCheck(): check cannot be nil")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.healthChecks == nil {
+		d.healthChecks = make(map[string]HealthCheck)
+	}
+	d.healthChecks[name] = check
+}
+
+// Runs all registered health checks concurrently.
+// Check which does not return within timeout is considered failed.
+func (d *Daemon) checkHealth() *HealthReport {
+	d.mu.Lock()
+	checks := make(map[string]HealthCheck, len(d.healthChecks))
+	for name, check := range d.healthChecks {
+		checks[name] = check
+	}
+	d.mu.Unlock()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check HealthCheck) {
+			done := make(chan error, 1)
+			go func() {
+				done <- check()
+			}()
+			select {
+			case err := <-done:
+				results <- result{name, err}
+			case <-time.After(healthCheckTimeout):
+				results <- result{name, fmt.Errorf(
+					"timed out after %s", healthCheckTimeout,
+				)}
+			}
+		}(name, check)
+	}
+	report := &HealthReport{Healthy: true, Checks: []HealthResult{}}
+	for range checks {
+		r := <-results
+		check := HealthResult{Name: r.name, Healthy: r.err == nil}
+		if r.err != nil {
+			check.Error = r.err.Error()
+			report.Healthy = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Name < report.Checks[j].Name
+	})
+	return report
+}
+
+// Built-in "health" command of control socket.
+func (d *Daemon) healthCommand([]string) (string, error) {
+	data, err := json.Marshal(d.checkHealth())
+	return string(data), err
+}
+
+// Runs health checks of running daemon via its control socket
+// (see HandleHealthCheck()) and returns their results.
+//
+// This function can also be used when writing your own daemon actions.
+func Health() (*HealthReport, error) {
+	return stdDaemon().Health()
+}
+
+// Runs health checks of running daemon via its control socket
+// (see HandleHealthCheck()) and returns their results.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) Health() (*HealthReport, error) {
+	const errLoc = "daemonigo.Health()"
+	reply, err := d.Call("health")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errLoc, err)
+	}
+	report := &HealthReport{}
+	if err = json.Unmarshal([]byte(reply), report); err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to parse health report, reason -> %w", errLoc, err,
+		)
+	}
+	return report, nil
+}
+
+// Waits during given timeout until all health checks
+// of running daemon pass.
+func (d *Daemon) waitHealthy(timeout time.Duration) error {
+	if !d.Control {
+		return fmt.Errorf(
+			"health checks of %s require control socket", d.AppName,
+		)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		report, err := d.Health()
+		if err == nil {
+			err = report.err()
+		}
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf(
+				"%s is not healthy after %s, reason -> %w",
+				d.AppName, timeout, err,
+			)
+		}
+		time.Sleep(healthPollInterval)
+	}
+}
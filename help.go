@@ -0,0 +1,112 @@
+package daemonigo
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Creates default "help" action of daemon.
+func (d *Daemon) helpAction() *Action {
+	return &Action{
+		Name:      "help",
+		Help:      "Print available actions or help of given action",
+		ArgsUsage: "[action]",
+		Run: func(args []string) error {
+			if len(args) == 0 {
+				d.printUsage(os.Stdout)
+				return nil
+			}
+			action := d.LookupAction(args[0])
+			if action == nil {
+				fmt.Println("Unknown action: " + args[0])
+				return newActionError(ExitInvalidArgs, fmt.Errorf(
+					"unknown action %q", args[0],
+				))
+			}
+			d.printActionHelp(os.Stdout, action)
+			return nil
+		},
+	}
+}
+
+// Prints usage of application with its flags and all not hidden
+// actions sorted by name.
+func (d *Daemon) printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [flags] <action> [arguments]\n", os.Args[0])
+	actions := make([]*Action, 0, len(d.actions))
+	for _, action := range d.actions {
+		if !action.Hidden {
+			actions = append(actions, action)
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	if len(actions) > 0 {
+		fmt.Fprintln(w, "\nActions:")
+		names, width := make([]string, len(actions)), 0
+		for i, action := range actions {
+			names[i] = strings.Join(
+				append([]string{action.Name}, action.Aliases...), ", ",
+			)
+			if len(names[i]) > width {
+				width = len(names[i])
+			}
+		}
+		for i, action := range actions {
+			line := fmt.Sprintf("  %-*s  %s", width, names[i], action.Help)
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+	printFlags(w, flag.CommandLine)
+	if d.LookupAction("help") != nil {
+		fmt.Fprintf(w, "\nRun \"%s help <action>\" for help of action.\n",
+			os.Args[0],
+		)
+	}
+}
+
+// Prints usage of given action with its description and flags.
+func (d *Daemon) printActionHelp(w io.Writer, action *Action) {
+	usage := fmt.Sprintf("Usage: %s [flags] %s", os.Args[0], action.Name)
+	if hasFlags(action.Flags) {
+		usage += " [action flags]"
+	}
+	if action.ArgsUsage != "" {
+		usage += " " + action.ArgsUsage
+	}
+	fmt.Fprintln(w, usage)
+	if len(action.Aliases) > 0 {
+		fmt.Fprintln(w, "Aliases:", strings.Join(action.Aliases, ", "))
+	}
+	if action.Help != "" {
+		fmt.Fprintln(w, "\n"+action.Help)
+	}
+	printFlags(w, action.Flags)
+}
+
+// Prints defaults of given flags, if there are any.
+func printFlags(w io.Writer, flags *flag.FlagSet) {
+	if !hasFlags(flags) {
+		return
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	out := flags.Output()
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+	flags.SetOutput(out)
+}
+
+// Reports whether given flag set defines any flags.
+func hasFlags(flags *flag.FlagSet) (has bool) {
+	if flags != nil {
+		flags.VisitAll(func(*flag.Flag) {
+			has = true
+		})
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package daemonigo
+
+import "os"
+
+// Hooks of daemon lifecycle, which allow to customize default actions
+// without replacing them. Nil hooks are skipped.
+type Hooks struct {
+	// Called in parent process by default actions before starting daemon.
+	// Returned error aborts starting.
+	BeforeStart func() error
+
+	// Called in parent process by default actions after daemon
+	// has been started successfully.
+	AfterStart func()
+
+	// Called in parent process by default actions when starting daemon
+	// fails with given error.
+	OnStartFailure func(err error)
+
+	// Called in parent process by default actions before stopping
+	// given daemon process. Returned error aborts stopping.
+	BeforeStop func(process *os.Process) error
+
+	// Called in parent process by default actions after daemon
+	// has been stopped successfully.
+	AfterStop func()
+
+	// Called in daemonized process after it has notified
+	// its readiness with Ready().
+	OnReady func()
+
+	// Called in daemonized process on its shutdown (see Shutdown()),
+	// which is triggered by signal of the first stop step (see StopPolicy)
+	// or SIGTERM, or by replacing process by its successor on reload.
+	// Hook is called as shutdown function with zero priority
+	// (see RegisterShutdown()).
+	OnShutdown func()
+}
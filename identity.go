@@ -0,0 +1,117 @@
+package daemonigo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Identity of process recorded in PID file along with its PID,
+// which allows to detect reuse of PID by another process.
+// Fields are empty if platform does not provide such information.
+type procIdentity struct {
+	// Start time of process since system boot in clock ticks.
+	startTime string
+
+	// Identifier of current system boot.
+	bootID string
+
+	// Absolute path to executable of process with resolved symlinks.
+	exe string
+}
+
+// Formats content of PID file for process with given PID:
+// PID on the first line, optionally followed by process start time,
+// boot ID and path to executable on separate lines.
+func pidFileContent(pid int) string {
+	startTime, _ := processStartTime(pid)
+	if startTime == "" {
+		return strconv.Itoa(pid)
+	}
+	exe, _ := processExe(pid)
+	return fmt.Sprintf("%d\n%s\n%s\n%s\n", pid, startTime, bootID(), exe)
+}
+
+// Parses content of PID file written by pidFileContent().
+func parsePidFile(content string) (pid int, id procIdentity, err error) {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if pid, err = strconv.Atoi(strings.TrimSpace(lines[0])); err != nil {
+		return
+	}
+	if len(lines) > 1 {
+		id.startTime = strings.TrimSpace(lines[1])
+	}
+	if len(lines) > 2 {
+		id.bootID = strings.TrimSpace(lines[2])
+	}
+	if len(lines) > 3 {
+		id.exe = strings.TrimSpace(lines[3])
+	}
+	return
+}
+
+// Returns absolute path to executable of current process
+// with resolved symlinks, or empty string if it is unknown.
+func currentExecutable() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
+// Returns absolute path to application executable with resolved symlinks.
+func (d *Daemon) executable() string {
+	path, err := filepath.Abs(d.AppPath)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
+// Verifies that process with given PID is the one,
+// which has written PID file, and that it runs application executable.
+// Executable is compared with the one recorded in PID file, or with
+// executable of current process for PID files written without it,
+// so result does not depend on current working directory.
+// Checks which are not supported by platform are skipped.
+func (d *Daemon) verifyProcess(pid int, recorded procIdentity) error {
+	if recorded.bootID != "" {
+		if current := bootID(); current != "" && current != recorded.bootID {
+			return fmt.Errorf("PID file has been written before system reboot")
+		}
+	}
+	if recorded.startTime != "" {
+		startTime, err := processStartTime(pid)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("process with PID %d does not exist", pid)
+		}
+		if err == nil && startTime != recorded.startTime {
+			return fmt.Errorf(
+				"process with PID %d has been started after PID file "+
+					"was written", pid,
+			)
+		}
+	}
+	if exe, err := processExe(pid); err == nil && exe != "" {
+		expected := recorded.exe
+		if expected == "" {
+			expected = currentExecutable()
+		}
+		if expected != "" && exe != expected {
+			return fmt.Errorf(
+				"process with PID %d runs %s instead of %s",
+				pid, exe, expected,
+			)
+		}
+	}
+	return nil
+}
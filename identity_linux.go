@@ -0,0 +1,78 @@
+package daemonigo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency of clock ticks reported by /proc, which is USER_HZ
+// and equals 100 on all architectures supported by Go.
+const clockTicks = 100
+
+// Returns start time of process with given PID since system boot
+// in clock ticks, as reported by /proc/<pid>/stat.
+func processStartTime(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	// process name may contain spaces and parentheses,
+	// so fields are counted after its closing parenthesis
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	// start time is the 22nd field, and fields start with the 3rd one
+	if len(fields) < 20 {
+		return "", fmt.Errorf("bad format of /proc/%d/stat", pid)
+	}
+	return fields[19], nil
+}
+
+// Returns identifier of current system boot.
+func bootID() string {
+	data, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Returns path to executable of process with given PID.
+func processExe(pid int) (string, error) {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", err
+	}
+	// executable could have been replaced while process is running
+	return strings.TrimSuffix(exe, " (deleted)"), nil
+}
+
+// Returns time when process has been started, calculated from its
+// start time since system boot in clock ticks (see processStartTime()),
+// or zero time if it cannot be calculated.
+func processStartedAt(startTime string) time.Time {
+	ticks, err := strconv.ParseInt(startTime, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		boot, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Unix(boot, 0).Add(
+			time.Duration(ticks) * time.Second / clockTicks,
+		)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package daemonigo
+
+import "time"
+
+// Returns start time of process with given PID since system boot.
+// Not supported on this platform.
+func processStartTime(pid int) (string, error) {
+	return "", nil
+}
+
+// Returns identifier of current system boot.
+// Not supported on this platform.
+func bootID() string {
+	return ""
+}
+
+// Returns path to executable of process with given PID.
+// Not supported on this platform.
+func processExe(pid int) (string, error) {
+	return "", nil
+}
+
+// Returns time when process has been started.
+// Not supported on this platform.
+func processStartedAt(startTime string) time.Time {
+	return time.Time{}
+}
@@ -0,0 +1,42 @@
+package daemonigo
+
+import "testing"
+
+func TestParsePidFile(t *testing.T) {
+	for _, tc := range []struct {
+		content string
+		pid     int
+		id      procIdentity
+		fails   bool
+	}{
+		{content: "123", pid: 123},
+		{content: " 42 \n", pid: 42},
+		{
+			content: "123\n4567\nboot-id\n",
+			pid:     123,
+			id:      procIdentity{startTime: "4567", bootID: "boot-id"},
+		},
+		{
+			content: "123\n4567\nboot-id\n/usr/bin/app\n",
+			pid:     123,
+			id: procIdentity{
+				startTime: "4567", bootID: "boot-id", exe: "/usr/bin/app",
+			},
+		},
+		{content: "", fails: true},
+		{content: "abc\n4567\n", fails: true},
+	} {
+		pid, id, err := parsePidFile(tc.content)
+		if tc.fails {
+			if err == nil {
+				t.Errorf("parsePidFile(%q) succeeded; want error", tc.content)
+			}
+			continue
+		}
+		if err != nil || pid != tc.pid || id != tc.id {
+			t.Errorf("parsePidFile(%q) = %d, %+v, %v; want %d, %+v",
+				tc.content, pid, id, err, tc.pid, tc.id,
+			)
+		}
+	}
+}
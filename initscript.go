@@ -0,0 +1,264 @@
+package daemonigo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Formats of init scripts generated by InitScript().
+const (
+	// SysV init script with LSB header.
+	InitSysV = "sysv"
+
+	// OpenRC service script.
+	InitOpenRC = "openrc"
+)
+
+// Default directory for installing init scripts.
+const initScriptDir = "/etc/init.d"
+
+// Names of system facility in different service managers.
+type facility struct {
+	lsb, openrc, systemd string
+}
+
+// System facilities, which can be used in Dependencies setting.
+var facilities = map[string]facility{
+	"network":   {"$network", "net", "network-online.target"},
+	"local_fs":  {"$local_fs", "localmount", "local-fs.target"},
+	"remote_fs": {"$remote_fs", "netmount", "remote-fs.target"},
+	"syslog":    {"$syslog", "logger", ""},
+	"named":     {"$named", "dns", "nss-lookup.target"},
+	"time":      {"$time", "ntp-client", "time-sync.target"},
+	"portmap":   {"$portmap", "rpcbind", "rpcbind.target"},
+}
+
+// Returns names of Dependencies in given service manager.
+// Names, which are not known facilities, are converted by given function.
+func (d *Daemon) dependencies(
+	name func(facility) string, other func(string) string,
+) []string {
+	deps := make([]string, 0, len(d.Dependencies))
+	for _, dep := range d.Dependencies {
+		f, ok := facilities[dep]
+		switch {
+		case !ok:
+			deps = append(deps, other(dep))
+		case name(f) != "":
+			deps = append(deps, name(f))
+		}
+	}
+	return deps
+}
+
+// Template of SysV init script.
+var sysvScriptTmpl = template.Must(template.New("sysv").Parse(
+	`#!/bin/sh
+#
+# chkconfig: 2345 20 80
+# description: {{.Description}}
+# pidfile: {{.PidFile}}
+#
+### BEGIN INIT INFO
+# Provides:          {{.Name}}
+# Required-Start:    {{.Requires}}
+# Required-Stop:     {{.Requires}}
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: {{.Description}}
+# Description:       {{.Description}}
+### END INIT INFO
+
+cd {{.QuotedWorkDir}} || exit 1
+
+case "$1" in
+	start|stop|status|restart|reload)
+		exec {{.Command}} "$1"
+		;;
+	force-reload)
+		exec {{.Command}} reload
+		;;
+	*)
+		echo "Usage: $0 {start|stop|status|restart|reload|force-reload}" >&2
+		exit 2
+		;;
+esac
+`))
+
+// Template of OpenRC service script.
+var openrcScriptTmpl = template.Must(template.New("openrc").Parse(
+	`#!/sbin/openrc-run
+
+description={{.QuotedDescription}}
+pidfile={{.QuotedPidFile}}
+extra_started_commands="reload"
+
+depend() {
+{{- if .Needs}}
+	need {{.Needs}}
+{{- end}}
+	use logger net
+}
+
+daemon_action() {
+	cd {{.QuotedWorkDir}} || return 1
+	{{.Command}} "$@"
+}
+
+start() {
+	ebegin "Starting ${RC_SVCNAME}"
+	daemon_action start
+	eend $?
+}
+
+stop() {
+	ebegin "Stopping ${RC_SVCNAME}"
+	daemon_action stop
+	eend $?
+}
+
+reload() {
+	ebegin "Reloading ${RC_SVCNAME}"
+	daemon_action reload
+	eend $?
+}
+
+status() {
+	daemon_action status
+}
+`))
+
+// Returns init script of daemon in given format (InitSysV or InitOpenRC),
+// which delegates its commands to default daemon actions.
+//
+// Command line flags preceding action name are passed to daemon
+// along with ExtraArgs (see ArgsFilter).
+func InitScript(format string) (string, error) {
+	return stdDaemon().InitScript(format)
+}
+
+// Returns init script of daemon in given format (InitSysV or InitOpenRC),
+// which delegates its commands to default daemon actions.
+//
+// Command line flags preceding action name are passed to daemon
+// along with ExtraArgs (see ArgsFilter).
+func (d *Daemon) InitScript(format string) (string, error) {
+	const errLoc = "daemonigo.InitScript()"
+	exe, err := filepath.Abs(d.AppPath)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to resolve absolute path of %s, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	pidFile, err := filepath.Abs(d.PidFile)
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to resolve absolute path of PID file, reason -> %w",
+			errLoc, err,
+		)
+	}
+	// relative paths are resolved against current directory,
+	// which is already WorkDir if Daemonize() has been called
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to get working directory, reason -> %w", errLoc, err,
+		)
+	}
+	args := append([]string{exe}, d.daemonArgs()...)
+	for i := range args {
+		args[i] = shellQuote(args[i])
+	}
+	description := d.Description
+	if description == "" {
+		description = d.AppName
+	}
+	data := map[string]string{
+		"Name":              d.AppName,
+		"Description":       strings.Join(strings.Fields(description), " "),
+		"QuotedDescription": shellQuote(description),
+		"PidFile":           pidFile,
+		"QuotedPidFile":     shellQuote(pidFile),
+		"QuotedWorkDir":     shellQuote(workDir),
+		"Command":           strings.Join(args, " "),
+	}
+
+	var tmpl *template.Template
+	switch format {
+	case InitSysV:
+		tmpl = sysvScriptTmpl
+		requires := []string{"$remote_fs", "$syslog"}
+		for _, dep := range d.dependencies(
+			func(f facility) string { return f.lsb },
+			func(name string) string { return name },
+		) {
+			if dep != "$remote_fs" && dep != "$syslog" {
+				requires = append(requires, dep)
+			}
+		}
+		data["Requires"] = strings.Join(requires, " ")
+	case InitOpenRC:
+		tmpl = openrcScriptTmpl
+		data["Needs"] = strings.Join(d.dependencies(
+			func(f facility) string { return f.openrc },
+			func(name string) string { return name },
+		), " ")
+	default:
+		return "", fmt.Errorf(
+			"%s: unknown format %q of init script", errLoc, format,
+		)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to render init script, reason -> %w", errLoc, err,
+		)
+	}
+	return buf.String(), nil
+}
+
+// Writes init script of daemon in given format (see InitScript())
+// into given directory as executable file named after AppName.
+// Returns path of written file.
+func InstallInitScript(format, dir string) (string, error) {
+	return stdDaemon().InstallInitScript(format, dir)
+}
+
+// Writes init script of daemon in given format (see InitScript())
+// into given directory as executable file named after AppName.
+// Returns path of written file.
+func (d *Daemon) InstallInitScript(format, dir string) (string, error) {
+	const errLoc = "daemonigo.InstallInitScript()"
+	script, err := d.InitScript(format)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", errLoc, err)
+	}
+	path := filepath.Join(dir, d.AppName)
+	if err = os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to write init script, reason -> %w", errLoc, err,
+		)
+	}
+	// permissions of existing file are not changed by WriteFile()
+	if err = os.Chmod(path, 0755); err != nil {
+		return "", fmt.Errorf(
+			"%s: failed to make init script executable, reason -> %w",
+			errLoc, err,
+		)
+	}
+	return path, nil
+}
+
+// Quotes argument of command line in shell script.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyz"+
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:=@%+,") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
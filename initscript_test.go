@@ -0,0 +1,22 @@
+package daemonigo
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	for _, tc := range []struct {
+		arg, expected string
+	}{
+		{"/usr/bin/app", "/usr/bin/app"},
+		{"-name=value,other:1@host+2%", "-name=value,other:1@host+2%"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"$HOME", "'$HOME'"},
+		{"it's", `'it'\''s'`},
+		{`say "hi"`, `'say "hi"'`},
+		{"a;rm -rf /", "'a;rm -rf /'"},
+	} {
+		if got := shellQuote(tc.arg); got != tc.expected {
+			t.Errorf("shellQuote(%q) = %s; want %s", tc.arg, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package daemonigo
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Listener or packet connection which can be passed to another process.
+type fileConn interface {
+	io.Closer
+	File() (*os.File, error)
+}
+
+// Name of environment variable which holds names and file descriptors
+// of listeners inherited by daemonized process,
+// in format "name:fd,name:fd".
+func (d *Daemon) listenEnvVarName() string {
+	return d.EnvVarName + "_LISTEN_FDS"
+}
+
+// Takes listeners inherited by daemonized process from its predecessor,
+// so they can be resumed later by Listen().
+func (d *Daemon) takeInheritedListeners() {
+	name := d.listenEnvVarName()
+	value := os.Getenv(name)
+	os.Unsetenv(name)
+	if value == "" {
+		return
+	}
+	for _, pair := range strings.Split(value, ",") {
+		i := strings.LastIndex(pair, ":")
+		if i < 1 {
+			continue
+		}
+		fd, err := strconv.Atoi(pair[i+1:])
+		if err != nil || fd < 3 {
+			continue
+		}
+		syscall.CloseOnExec(fd)
+		d.inherit(pair[:i], os.NewFile(uintptr(fd), pair[:i]))
+	}
+}
+
+// Registers inherited file with given name, so it can be resumed
+// later by Listen() or ListenPacket().
+// File is closed if another one with the same name is already inherited.
+func (d *Daemon) inherit(name string, file *os.File) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.inherited[name]; exists {
+		file.Close()
+		return
+	}
+	if d.inherited == nil {
+		d.inherited = make(map[string]*os.File)
+	}
+	d.inherited[name] = file
+}
+
+// Duplicates inherited files, which are not resumed yet,
+// to pass them to another process.
+func (d *Daemon) dupInherited() (names []string, files []*os.File, e error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, file := range d.inherited {
+		fd, err := syscall.Dup(int(file.Fd()))
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, nil, fmt.Errorf(
+				"failed to duplicate inherited listener %q, reason -> %w",
+				name, err,
+			)
+		}
+		syscall.CloseOnExec(fd)
+		names = append(names, name)
+		files = append(files, os.NewFile(uintptr(fd), name))
+	}
+	return
+}
+
+// Passes given files to command as listeners with given names,
+// which are inherited by started daemonized process.
+func (d *Daemon) passListeners(
+	cmd *exec.Cmd, names []string, files []*os.File,
+) {
+	if len(files) == 0 {
+		return
+	}
+	fds := make([]string, 0, len(files))
+	for i, file := range files {
+		cmd.ExtraFiles = append(cmd.ExtraFiles, file)
+		fds = append(fds, fmt.Sprintf("%s:%d", names[i], 2+len(cmd.ExtraFiles)))
+	}
+	prefix := d.listenEnvVarName() + "="
+	for i, env := range cmd.Env {
+		if strings.HasPrefix(env, prefix) {
+			cmd.Env[i] = env + "," + strings.Join(fds, ",")
+			return
+		}
+	}
+	cmd.Env = append(cmd.Env, prefix+strings.Join(fds, ","))
+}
+
+// Announces on the local network address like net.Listen() does
+// and registers listener with given name to be passed to new
+// daemonized process on reload (see Reload()).
+//
+// If daemonized process has been started by reload, then listener
+// with the same name is resumed from predecessor process instead of
+// announcing the address again, so no connections are refused.
+// The same way listeners passed by socket activation are resumed
+// (see InheritedListeners()).
+//
+// Name must be unique and cannot contain ':' and ',' symbols.
+func Listen(name, network, address string) (net.Listener, error) {
+	return stdDaemon().Listen(name, network, address)
+}
+
+// Announces on the local network address like net.Listen() does
+// and registers listener with given name to be passed to new
+// daemonized process on reload (see Reload()).
+//
+// If daemonized process has been started by reload, then listener
+// with the same name is resumed from predecessor process instead of
+// announcing the address again, so no connections are refused.
+// The same way listeners passed by socket activation are resumed
+// (see InheritedListeners()).
+//
+// Name must be unique and cannot contain ':' and ',' symbols.
+func (d *Daemon) Listen(name, network, address string) (net.Listener, error) {
+	conn, err := d.register("daemonigo.Listen()", name,
+		func(file *os.File) (io.Closer, error) {
+			if file != nil {
+				return net.FileListener(file)
+			}
+			return net.Listen(network, address)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(net.Listener), nil
+}
+
+// Announces on the local network address like net.ListenPacket() does
+// and registers connection with given name to be passed to new
+// daemonized process on reload (see Reload()).
+//
+// Connection is resumed from predecessor process or socket activation
+// the same way as Listen() does.
+//
+// Name must be unique and cannot contain ':' and ',' symbols.
+func ListenPacket(name, network, address string) (net.PacketConn, error) {
+	return stdDaemon().ListenPacket(name, network, address)
+}
+
+// Announces on the local network address like net.ListenPacket() does
+// and registers connection with given name to be passed to new
+// daemonized process on reload (see Reload()).
+//
+// Connection is resumed from predecessor process or socket activation
+// the same way as Listen() does.
+//
+// Name must be unique and cannot contain ':' and ',' symbols.
+func (d *Daemon) ListenPacket(
+	name, network, address string,
+) (net.PacketConn, error) {
+	conn, err := d.register("daemonigo.ListenPacket()", name,
+		func(file *os.File) (io.Closer, error) {
+			if file != nil {
+				return net.FilePacketConn(file)
+			}
+			return net.ListenPacket(network, address)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(net.PacketConn), nil
+}
+
+// Registers connection with given name, which is opened by given function.
+// Inherited file with the same name is passed to the function,
+// if there is one, otherwise nil is passed.
+func (d *Daemon) register(
+	errLoc, name string, open func(file *os.File) (io.Closer, error),
+) (io.Closer, error) {
+	if name == "" || strings.ContainsAny(name, ":,") {
+		return nil, fmt.Errorf("%s: bad listener name %q", errLoc, name)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.listeners[name]; exists {
+		return nil, fmt.Errorf(
+			"%s: listener %q is already registered", errLoc, name,
+		)
+	}
+
+	file, inherited := d.inherited[name]
+	delete(d.inherited, name)
+	conn, err := open(file)
+	if inherited {
+		file.Close()
+	}
+	switch {
+	case err != nil && inherited:
+		return nil, fmt.Errorf(
+			"%s: failed to resume inherited listener %q, reason -> %w",
+			errLoc, name, err,
+		)
+	case err != nil:
+		return nil, fmt.Errorf(
+			"%s: failed to listen, reason -> %w", errLoc, err,
+		)
+	}
+	fc, ok := conn.(fileConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf(
+			"%s: listener %q of type %T cannot be passed to another process",
+			errLoc, name, conn,
+		)
+	}
+
+	if d.listeners == nil {
+		d.listeners = make(map[string]fileConn)
+	}
+	d.listeners[name] = fc
+	return conn, nil
+}
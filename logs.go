@@ -0,0 +1,258 @@
+package daemonigo
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Interval of checking size of log files for rotation.
+const logCheckInterval = 10 * time.Second
+
+// Describes rotation of log files performed by daemonized process.
+// Rotated files are renamed with numeric suffix: "app.log.1" is the newest.
+type LogRotation struct {
+	// Maximum size of log file in bytes before it is rotated.
+	// Zero value disables rotation by size.
+	MaxSize int64
+
+	// Interval of rotating log files.
+	// Zero value disables rotation by time.
+	Interval time.Duration
+
+	// Number of rotated files to keep, at least one is kept always.
+	MaxBackups int
+}
+
+// Returns true if rotation is enabled at all.
+func (r LogRotation) enabled() bool {
+	return r.MaxSize > 0 || r.Interval > 0
+}
+
+// Opens log file for appending.
+// Newly created file is owned by User and Group (see DropPrivileges()).
+func (d *Daemon) openLogFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(
+		path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, d.LogFileMask,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.chownFile(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Redirects standard output and standard error of given command
+// to StdoutFile and StderrFile. Returns opened files,
+// which must be closed after command is started.
+func (d *Daemon) attachLogFiles(cmd *exec.Cmd) (files []*os.File, err error) {
+	if d.StdoutFile != "" {
+		var file *os.File
+		if file, err = d.openLogFile(d.StdoutFile); err != nil {
+			return
+		}
+		files = append(files, file)
+		cmd.Stdout = file
+	}
+	switch {
+	case d.StderrFile == "":
+	case d.StderrFile == d.StdoutFile:
+		cmd.Stderr = cmd.Stdout
+	default:
+		var file *os.File
+		if file, err = d.openLogFile(d.StderrFile); err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, file)
+		cmd.Stderr = file
+	}
+	return
+}
+
+// Starts reopening and, if rotate is set,
+// rotating log files in daemonized process.
+func (d *Daemon) watchLogs(rotate bool) {
+	if d.StdoutFile == "" && d.StderrFile == "" {
+		return
+	}
+	if d.ReopenSignal != nil {
+		d.HandleSignal(d.ReopenSignal, func(os.Signal) {
+			if err := d.ReopenLogs(); err != nil {
+				log.Println(err.Error())
+			}
+		})
+	}
+	if !rotate || !d.LogRotation.enabled() {
+		return
+	}
+	go func() {
+		rotated := time.Now()
+		for now := range time.NewTicker(logCheckInterval).C {
+			rotate := d.LogRotation.Interval > 0 &&
+				now.Sub(rotated) >= d.LogRotation.Interval
+			if d.LogRotation.MaxSize > 0 && !rotate {
+				rotate = d.logsOversized()
+			}
+			if !rotate {
+				continue
+			}
+			rotated = now
+			if err := d.RotateLogs(); err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}()
+}
+
+// Returns distinct paths of log files.
+func (d *Daemon) logFiles() (paths []string) {
+	if d.StdoutFile != "" {
+		paths = append(paths, d.StdoutFile)
+	}
+	if d.StderrFile != "" && d.StderrFile != d.StdoutFile {
+		paths = append(paths, d.StderrFile)
+	}
+	return
+}
+
+// Checks if any of log files exceeds maximum size.
+func (d *Daemon) logsOversized() bool {
+	for _, path := range d.logFiles() {
+		if info, err := os.Stat(path); err == nil &&
+			info.Size() >= d.LogRotation.MaxSize {
+			return true
+		}
+	}
+	return false
+}
+
+// Reopens log files of daemonized process,
+// so they can be rotated by external tools like logrotate.
+// Does nothing if StdoutFile and StderrFile are not set.
+func ReopenLogs() error {
+	return stdDaemon().ReopenLogs()
+}
+
+// Reopens log files of daemonized process,
+// so they can be rotated by external tools like logrotate.
+// Does nothing if StdoutFile and StderrFile are not set.
+func (d *Daemon) ReopenLogs() error {
+	const errLoc = "daemonigo.ReopenLogs()"
+	d.logsMu.Lock()
+	defer d.logsMu.Unlock()
+	if err := d.reopenLogs(); err != nil {
+		return fmt.Errorf(
+			"%s: failed to reopen log files, reason -> %w",
+			errLoc, err,
+		)
+	}
+	return nil
+}
+
+// Reopens log files and replaces standard output
+// and standard error descriptors with them.
+// Makes worker process to reopen them too in supervised mode.
+func (d *Daemon) reopenLogs() (err error) {
+	defer func() {
+		if err != nil || d.ReopenSignal == nil {
+			return
+		}
+		d.mu.Lock()
+		worker := d.worker
+		d.mu.Unlock()
+		if worker != nil {
+			err = worker.Signal(d.ReopenSignal)
+		}
+	}()
+	var stdout *os.File
+	if d.StdoutFile != "" {
+		file, err := d.openLogFile(d.StdoutFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err = dup2(int(file.Fd()), syscall.Stdout); err != nil {
+			return err
+		}
+		stdout = file
+	}
+	switch {
+	case d.StderrFile == "":
+	case d.StderrFile == d.StdoutFile:
+		return dup2(int(stdout.Fd()), syscall.Stderr)
+	default:
+		file, err := d.openLogFile(d.StderrFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return dup2(int(file.Fd()), syscall.Stderr)
+	}
+	return nil
+}
+
+// Rotates log files of daemonized process according to LogRotation
+// and reopens them.
+// Does nothing if StdoutFile and StderrFile are not set.
+func RotateLogs() error {
+	return stdDaemon().RotateLogs()
+}
+
+// Rotates log files of daemonized process according to LogRotation
+// and reopens them.
+// Does nothing if StdoutFile and StderrFile are not set.
+func (d *Daemon) RotateLogs() error {
+	const errLoc = "daemonigo.RotateLogs()"
+	d.logsMu.Lock()
+	defer d.logsMu.Unlock()
+	for _, path := range d.logFiles() {
+		if err := d.rotateLogFile(path); err != nil {
+			return fmt.Errorf(
+				"%s: failed to rotate log file %s, reason -> %w",
+				errLoc, path, err,
+			)
+		}
+	}
+	if err := d.reopenLogs(); err != nil {
+		return fmt.Errorf(
+			"%s: failed to reopen log files, reason -> %w",
+			errLoc, err,
+		)
+	}
+	return nil
+}
+
+// Shifts rotated copies of given log file
+// and renames the file to be the newest copy.
+func (d *Daemon) rotateLogFile(path string) error {
+	backups := d.LogRotation.MaxBackups
+	if backups < 1 {
+		backups = 1
+	}
+	name := func(n int) string {
+		return path + "." + strconv.Itoa(n)
+	}
+	if err := os.Remove(name(backups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := backups - 1; n > 0; n-- {
+		err := os.Rename(name(n), name(n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(path, name(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package notify implements systemd service notification protocol
+// (see sd_notify(3)), which is used by services of Type=notify
+// to report their state to service manager.
+//
+// Notifications are sent as datagrams to Unix socket,
+// whose address is passed to service in NOTIFY_SOCKET environment variable.
+// If service is not run by systemd, then sending notifications does nothing.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of environment variables passed by systemd to service.
+const (
+	// Address of socket to send notifications to.
+	SocketEnvVarName = "NOTIFY_SOCKET"
+
+	// Watchdog timeout in microseconds.
+	WatchdogUsecEnvVarName = "WATCHDOG_USEC"
+
+	// PID of process which is expected to send watchdog keep-alive pings.
+	WatchdogPidEnvVarName = "WATCHDOG_PID"
+)
+
+// States which can be sent to service manager.
+const (
+	// Service startup is finished, or reloading is finished.
+	Ready = "READY=1"
+
+	// Service is reloading its configuration.
+	// Ready must be sent after reloading is finished.
+	Reloading = "RELOADING=1"
+
+	// Service is beginning its shutdown.
+	Stopping = "STOPPING=1"
+
+	// Keep-alive ping of service watchdog.
+	Watchdog = "WATCHDOG=1"
+)
+
+// Returns state describing status of service in free form,
+// which is shown by service manager.
+func Status(msg string) string {
+	return "STATUS=" + strings.Join(strings.Fields(msg), " ")
+}
+
+// Returns state announcing PID of main process of service,
+// which is used when main process is replaced by another one.
+func MainPID(pid int) string {
+	return "MAINPID=" + strconv.Itoa(pid)
+}
+
+// Returns state with errno-style error code of service failure.
+func Errno(code int) string {
+	return "ERRNO=" + strconv.Itoa(code)
+}
+
+// Returns address of socket to send notifications to,
+// or empty string if service is not run with notification support.
+func Socket() string {
+	return os.Getenv(SocketEnvVarName)
+}
+
+// Reports whether process is run with notification support.
+func Enabled() bool {
+	return Socket() != ""
+}
+
+// Sends given states to service manager in one notification.
+// Returns false without error if process is not run
+// with notification support.
+func Send(states ...string) (sent bool, e error) {
+	return SendTo(Socket(), states...)
+}
+
+// Sends given states in one notification to socket with given address.
+// Addresses starting with '@' refer to Linux abstract namespace.
+// Returns false without error if address is empty.
+func SendTo(socket string, states ...string) (sent bool, e error) {
+	const errLoc = "notify.SendTo()"
+	if socket == "" {
+		return false, nil
+	}
+	if socket[0] != '/' && socket[0] != '@' {
+		return false, fmt.Errorf(
+			"%s: unsupported socket address %q", errLoc, socket,
+		)
+	}
+	if len(states) == 0 {
+		return false, errors.New(errLoc + ": no states to send")
+	}
+	conn, err := net.DialUnix(
+		"unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"},
+	)
+	if err != nil {
+		return false, fmt.Errorf(
+			"%s: failed to connect to %q, reason -> %w", errLoc, socket, err,
+		)
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf(
+			"%s: failed to send notification, reason -> %w", errLoc, err,
+		)
+	}
+	return true, nil
+}
+
+// Returns interval of watchdog keep-alive pings expected by service
+// manager from current process. Returns false if watchdog is disabled.
+//
+// Pings should be sent with Watchdog state at least twice
+// within returned interval.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv(WatchdogUsecEnvVarName), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv(WatchdogPidEnvVarName); pid != "" {
+		if p, err := strconv.Atoi(pid); err != nil || p != os.Getpid() {
+			return 0, false
+		}
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
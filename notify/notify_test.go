@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Listens Unix datagram socket standing in for service manager.
+func listen(t *testing.T, addr string) *net.UnixConn {
+	t.Helper()
+	conn, err := net.ListenUnixgram(
+		"unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"},
+	)
+	if err != nil {
+		t.Fatalf("failed to listen %q: %s", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Reads single datagram received by socket.
+func receive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFromUnix(buf)
+	if err != nil {
+		t.Fatalf("failed to receive notification: %s", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSendTo(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+
+	for _, tc := range []struct {
+		states   []string
+		expected string
+	}{
+		{[]string{Ready}, "READY=1"},
+		{
+			[]string{Ready, Status("serving  on\n:8080")},
+			"READY=1\nSTATUS=serving on :8080",
+		},
+		{
+			[]string{MainPID(42), Ready},
+			"MAINPID=42\nREADY=1",
+		},
+		{[]string{Stopping, Errno(5)}, "STOPPING=1\nERRNO=5"},
+	} {
+		sent, err := SendTo(addr, tc.states...)
+		if err != nil || !sent {
+			t.Fatalf("SendTo(%q) = %t, %v; want sent", tc.states, sent, err)
+		}
+		if got := receive(t, conn); got != tc.expected {
+			t.Errorf("SendTo(%q) sent %q; want %q", tc.states, got, tc.expected)
+		}
+	}
+}
+
+func TestSendToAbstract(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract namespace is supported on Linux only")
+	}
+	addr := "@daemonigo-notify-test-" + filepath.Base(t.TempDir())
+	conn := listen(t, addr)
+	if sent, err := SendTo(addr, Watchdog); err != nil || !sent {
+		t.Fatalf("SendTo(%q) = %t, %v; want sent", addr, sent, err)
+	}
+	if got := receive(t, conn); got != Watchdog {
+		t.Errorf("SendTo(%q) sent %q; want %q", addr, got, Watchdog)
+	}
+}
+
+func TestSendToEmptyAddress(t *testing.T) {
+	if sent, err := SendTo("", Ready); sent || err != nil {
+		t.Errorf("SendTo(\"\") = %t, %v; want false, nil", sent, err)
+	}
+}
+
+func TestSendToBadAddress(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.sock")
+	for _, addr := range []string{"relative.sock", missing} {
+		if sent, err := SendTo(addr, Ready); sent || err == nil {
+			t.Errorf("SendTo(%q) = %t, %v; want error", addr, sent, err)
+		}
+	}
+}
+
+func TestSendToNoStates(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	listen(t, addr)
+	if sent, err := SendTo(addr); sent || err == nil {
+		t.Errorf("SendTo(%q) = %t, %v; want error", addr, sent, err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	t.Setenv(SocketEnvVarName, "")
+	if sent, err := Send(Ready); sent || err != nil {
+		t.Errorf("Send() without socket = %t, %v; want false, nil", sent, err)
+	}
+
+	addr := filepath.Join(t.TempDir(), "notify.sock")
+	conn := listen(t, addr)
+	t.Setenv(SocketEnvVarName, addr)
+	if !Enabled() {
+		t.Fatalf("Enabled() = false; want true")
+	}
+	if sent, err := Send(Ready); err != nil || !sent {
+		t.Fatalf("Send() = %t, %v; want sent", sent, err)
+	}
+	if got := receive(t, conn); got != Ready {
+		t.Errorf("Send() sent %q; want %q", got, Ready)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	for _, tc := range []struct {
+		usec, pid string
+		expected  time.Duration
+		enabled   bool
+	}{
+		{"", "", 0, false},
+		{"bad", "", 0, false},
+		{"0", "", 0, false},
+		{"2000000", "", 2 * time.Second, true},
+		{"500000", strconv.Itoa(os.Getpid()), 500 * time.Millisecond, true},
+		{"500000", strconv.Itoa(os.Getpid() + 1), 0, false},
+	} {
+		t.Setenv(WatchdogUsecEnvVarName, tc.usec)
+		t.Setenv(WatchdogPidEnvVarName, tc.pid)
+		interval, enabled := WatchdogInterval()
+		if interval != tc.expected || enabled != tc.enabled {
+			t.Errorf(
+				"WatchdogInterval() with usec=%q pid=%q = %s, %t; want %s, %t",
+				tc.usec, tc.pid, interval, enabled, tc.expected, tc.enabled,
+			)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package daemonigo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Handle of daemon process based on Linux pidfd.
+type pidfdHandle struct {
+	fd int
+}
+
+// Opens pidfd of process with given PID.
+// Fails on kernels older than 5.3.
+func openPidfd(pid int) (procHandle, error) {
+	fd, _, errno := syscall.Syscall(sysPidfdOpen, uintptr(pid), 0, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	syscall.CloseOnExec(int(fd))
+	return &pidfdHandle{fd: int(fd)}, nil
+}
+
+func (h *pidfdHandle) signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %s", sig)
+	}
+	_, _, errno := syscall.Syscall6(
+		sysPidfdSendSignal, uintptr(h.fd), uintptr(s), 0, 0, 0, 0,
+	)
+	switch errno {
+	case 0:
+		return nil
+	case syscall.ESRCH:
+		return os.ErrProcessDone
+	}
+	return errno
+}
+
+func (h *pidfdHandle) wait(
+	ctx context.Context, timeout time.Duration,
+) (bool, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return false, err
+	}
+	defer syscall.Close(epfd)
+	// pidfd becomes readable when process exits
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(h.fd)}
+	if err = syscall.EpollCtl(
+		epfd, syscall.EPOLL_CTL_ADD, h.fd, &event,
+	); err != nil {
+		return false, err
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	events := make([]syscall.EpollEvent, 1)
+	for {
+		if err = ctx.Err(); err != nil {
+			return false, fmt.Errorf(
+				"stopping aborted, reason -> %w", err,
+			)
+		}
+		// context is checked at least every poll interval
+		wait := stopPollInterval
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return false, nil
+			}
+			if left < wait {
+				wait = left
+			}
+		}
+		n, err := syscall.EpollWait(epfd, events, int(wait/time.Millisecond))
+		switch {
+		case err == syscall.EINTR:
+		case err != nil:
+			return false, err
+		case n > 0:
+			return true, nil
+		}
+	}
+}
+
+func (h *pidfdHandle) close() {
+	syscall.Close(h.fd)
+}
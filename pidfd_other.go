@@ -0,0 +1,11 @@
+//go:build !linux
+
+package daemonigo
+
+import "errors"
+
+// Opens pidfd of process with given PID.
+// Not supported on this platform.
+func openPidfd(pid int) (procHandle, error) {
+	return nil, errors.New("pidfd is not supported")
+}
@@ -0,0 +1,10 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+
+package daemonigo
+
+// Numbers of pidfd system calls in the unified system call table,
+// which is used by all architectures except MIPS.
+const (
+	sysPidfdSendSignal = 424
+	sysPidfdOpen       = 434
+)
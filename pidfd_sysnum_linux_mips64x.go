@@ -0,0 +1,9 @@
+//go:build linux && (mips64 || mips64le)
+
+package daemonigo
+
+// Numbers of pidfd system calls for MIPS n64 ABI.
+const (
+	sysPidfdSendSignal = 5424
+	sysPidfdOpen       = 5434
+)
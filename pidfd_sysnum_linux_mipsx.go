@@ -0,0 +1,9 @@
+//go:build linux && (mips || mipsle)
+
+package daemonigo
+
+// Numbers of pidfd system calls for MIPS o32 ABI.
+const (
+	sysPidfdSendSignal = 4424
+	sysPidfdOpen       = 4434
+)
@@ -0,0 +1,171 @@
+package daemonigo
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// Resolves user and groups configured for daemonized process
+// into numeric credential. Names and numeric IDs are both accepted.
+// If Group is not set, primary group of User is used.
+func (d *Daemon) credential() (*syscall.Credential, error) {
+	cred := &syscall.Credential{
+		Uid: uint32(os.Getuid()),
+		Gid: uint32(os.Getgid()),
+	}
+	if d.User != "" {
+		u, err := user.Lookup(d.User)
+		if _, ok := err.(user.UnknownUserError); ok {
+			u, err = user.LookupId(d.User)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cred.Uid, err = parseID(u.Uid); err != nil {
+			return nil, err
+		}
+		if cred.Gid, err = parseID(u.Gid); err != nil {
+			return nil, err
+		}
+	}
+	if d.Group != "" {
+		gid, err := lookupGroup(d.Group)
+		if err != nil {
+			return nil, err
+		}
+		cred.Gid = gid
+	}
+	cred.Groups = []uint32{}
+	for _, name := range d.SupplementaryGroups {
+		gid, err := lookupGroup(name)
+		if err != nil {
+			return nil, err
+		}
+		cred.Groups = append(cred.Groups, gid)
+	}
+	return cred, nil
+}
+
+// Resolves group name or numeric ID into numeric ID.
+func lookupGroup(name string) (uint32, error) {
+	g, err := user.LookupGroup(name)
+	if _, ok := err.(user.UnknownGroupError); ok {
+		g, err = user.LookupGroupId(name)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return parseID(g.Gid)
+}
+
+// Parses numeric user or group ID.
+func parseID(id string) (uint32, error) {
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("bad numeric ID %q", id)
+	}
+	return uint32(n), nil
+}
+
+// Checks if privileges of current process should be dropped.
+func (d *Daemon) mustDropPrivileges() bool {
+	return (d.User != "" || d.Group != "") && os.Geteuid() == 0
+}
+
+// Changes owner of given file to user and group configured
+// for daemonized process, if current process is privileged.
+// Does nothing if file does not exist.
+func (d *Daemon) chownFile(path string) error {
+	if path == "" || !d.mustDropPrivileges() {
+		return nil
+	}
+	cred, err := d.credential()
+	if err != nil {
+		return err
+	}
+	err = os.Lchown(path, int(cred.Uid), int(cred.Gid))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Returns paths of files which must be accessible
+// by daemonized process after dropping its privileges.
+func (d *Daemon) ownedFiles() []string {
+	files := append(
+		[]string{d.PidFile, d.pidFileSibling(".state")}, d.logFiles()...,
+	)
+	if d.Control {
+		files = append(files, d.controlPath())
+	}
+	return files
+}
+
+// Drops privileges of daemonized process to User, Group
+// and SupplementaryGroups. Should be called after everything requiring
+// privileges (like binding to ports below 1024) is done.
+//
+// Owner of PID file, log files and control socket is changed to User
+// and Group, so daemonized process keeps being able to operate with them.
+//
+// Does nothing if neither User nor Group is set,
+// or current process is not privileged.
+func DropPrivileges() error {
+	return stdDaemon().DropPrivileges()
+}
+
+// Drops privileges of daemonized process to User, Group
+// and SupplementaryGroups. Should be called after everything requiring
+// privileges (like binding to ports below 1024) is done.
+//
+// Owner of PID file, log files and control socket is changed to User
+// and Group, so daemonized process keeps being able to operate with them.
+//
+// Does nothing if neither User nor Group is set,
+// or current process is not privileged.
+func (d *Daemon) DropPrivileges() error {
+	const errLoc = "daemonigo.DropPrivileges()"
+	if !d.mustDropPrivileges() {
+		return nil
+	}
+	cred, err := d.credential()
+	if err != nil {
+		return fmt.Errorf(
+			"%s: failed to resolve user and groups, reason -> %w",
+			errLoc, err,
+		)
+	}
+	for _, path := range d.ownedFiles() {
+		if err = d.chownFile(path); err != nil {
+			return fmt.Errorf(
+				"%s: failed to change owner of %s, reason -> %w",
+				errLoc, path, err,
+			)
+		}
+	}
+	groups := make([]int, 0, len(cred.Groups))
+	for _, gid := range cred.Groups {
+		groups = append(groups, int(gid))
+	}
+	if err = syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf(
+			"%s: failed to set supplementary groups, reason -> %w",
+			errLoc, err,
+		)
+	}
+	if err = syscall.Setgid(int(cred.Gid)); err != nil {
+		return fmt.Errorf(
+			"%s: failed to set group, reason -> %w", errLoc, err,
+		)
+	}
+	if err = syscall.Setuid(int(cred.Uid)); err != nil {
+		return fmt.Errorf(
+			"%s: failed to set user, reason -> %w", errLoc, err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package daemonigo
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Handle of daemon process used to control it while stopping.
+type procHandle interface {
+	// Sends signal to process.
+	// Returns os.ErrProcessDone if process has already exited.
+	signal(sig os.Signal) error
+
+	// Waits for process to stop during given timeout.
+	// Zero timeout means waiting until context is done.
+	wait(ctx context.Context, timeout time.Duration) (stopped bool, e error)
+
+	// Releases resources of handle.
+	close()
+}
+
+// Handle of daemon process which checks status of daemon
+// by polling its PID file.
+type pollHandle struct {
+	d       *Daemon
+	process *os.Process
+}
+
+func (h *pollHandle) signal(sig os.Signal) error {
+	return h.process.Signal(sig)
+}
+
+func (h *pollHandle) wait(
+	ctx context.Context, timeout time.Duration,
+) (bool, error) {
+	return h.d.waitStopped(ctx, timeout)
+}
+
+func (h *pollHandle) close() {}
+
+// Opens handle of given daemon process.
+//
+// Uses pidfd on platforms supporting it, so signals cannot be delivered
+// to another process reusing the same PID, and exit of process is waited
+// without polling. Falls back to polling PID file otherwise.
+func (d *Daemon) openProcess(process *os.Process) procHandle {
+	h, err := openPidfd(process.Pid)
+	if err != nil {
+		return &pollHandle{d: d, process: process}
+	}
+	// PID could have been reused before pidfd was opened,
+	// so make sure it still belongs to daemon
+	isRunning, pr, err := d.Status()
+	if err != nil || !isRunning || pr.Pid != process.Pid {
+		h.close()
+		return &pollHandle{d: d, process: process}
+	}
+	return h
+}
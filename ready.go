@@ -0,0 +1,316 @@
+package daemonigo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tyranron/daemonigo/notify"
+)
+
+// Messages of readiness notification protocol.
+// Daemonized process writes exactly one of them
+// into inherited pipe, terminated by a new line.
+const (
+	readyMsg  = "READY"
+	failedMsg = "FAILED"
+	busyMsg   = "BUSY"
+)
+
+// Pipe used to receive readiness notification from daemonized process.
+type readyPipe struct {
+	r, w *os.File
+
+	// Files passed to daemonized process,
+	// which must be closed in current process after its start.
+	files []*os.File
+}
+
+// Closes files passed to daemonized process and writing end of pipe.
+func (p *readyPipe) closePassed() {
+	p.w.Close()
+	for _, file := range p.files {
+		file.Close()
+	}
+}
+
+// Closes both ends of pipe and passed files. Does nothing on nil pipe.
+func (p *readyPipe) close() {
+	if p != nil {
+		p.r.Close()
+		p.closePassed()
+	}
+}
+
+// Name of environment variable which holds file descriptor
+// of readiness notification pipe in daemonized process.
+func (d *Daemon) readyEnvVarName() string {
+	return d.EnvVarName + "_READY_FD"
+}
+
+// Creates readiness notification pipe for given command
+// and passes its writing end to the command as inherited file.
+// Given files are closed along with writing end.
+func (d *Daemon) attachReadyPipe(cmd *exec.Cmd, files []*os.File) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+	cmd.Env = append(cmd.Env, fmt.Sprintf(
+		"%s=%d", d.readyEnvVarName(), 2+len(cmd.ExtraFiles),
+	))
+	d.mu.Lock()
+	if d.readyPipes == nil {
+		d.readyPipes = make(map[*exec.Cmd]*readyPipe)
+	}
+	d.readyPipes[cmd] = &readyPipe{r: r, w: w, files: files}
+	d.mu.Unlock()
+	return nil
+}
+
+// Returns readiness notification pipe of given command if any
+// and forgets about it.
+func (d *Daemon) takeReadyPipe(cmd *exec.Cmd) *readyPipe {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p := d.readyPipes[cmd]
+	delete(d.readyPipes, cmd)
+	return p
+}
+
+// Waits until daemonized process, started with given command,
+// notifies about its readiness or failure, exits or timeout passes.
+// Command must be prepared by StartCommand() and already started.
+//
+// If process notifies about its failure, then returned error contains
+// message of daemonized process. If timeout passes without any
+// notification, then process seems to be successfully started,
+// unless RequireReady is set.
+//
+// This function can also be used when writing your own daemon actions.
+func WaitReady(cmd *exec.Cmd, timeout time.Duration) error {
+	return stdDaemon().WaitReady(cmd, timeout)
+}
+
+// Waits until daemonized process, started with given command,
+// notifies about its readiness or failure, exits or timeout passes.
+// Command must be prepared by StartCommand() and already started.
+//
+// If process notifies about its failure, then returned error contains
+// message of daemonized process. If timeout passes without any
+// notification, then process seems to be successfully started,
+// unless RequireReady is set.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) WaitReady(cmd *exec.Cmd, timeout time.Duration) error {
+	return d.WaitReadyContext(context.Background(), cmd, timeout)
+}
+
+// Waits until daemonized process, started with given command,
+// notifies about its readiness or failure, exits or timeout passes.
+// Command must be prepared by StartCommand() and already started.
+//
+// If process notifies about its failure, then returned error contains
+// message of daemonized process. If timeout passes without any
+// notification, then process seems to be successfully started,
+// unless RequireReady is set.
+// Waiting is aborted when given context is done.
+//
+// This function can also be used when writing your own daemon actions.
+func WaitReadyContext(
+	ctx context.Context, cmd *exec.Cmd, timeout time.Duration,
+) error {
+	return stdDaemon().WaitReadyContext(ctx, cmd, timeout)
+}
+
+// Waits until daemonized process, started with given command,
+// notifies about its readiness or failure, exits or timeout passes.
+// Command must be prepared by StartCommand() and already started.
+//
+// If process notifies about its failure, then returned error contains
+// message of daemonized process. If timeout passes without any
+// notification, then process seems to be successfully started,
+// unless RequireReady is set.
+// Waiting is aborted when given context is done.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) WaitReadyContext(
+	ctx context.Context, cmd *exec.Cmd, timeout time.Duration,
+) error {
+	const errLoc = "daemonigo.WaitReadyContext()"
+	var notified chan string
+	if p := d.takeReadyPipe(cmd); p != nil {
+		// writing end must be closed in current process,
+		// otherwise reading never meets EOF
+		p.closePassed()
+		notified = make(chan string, 1)
+		go func() {
+			defer p.r.Close()
+			line, _ := bufio.NewReader(p.r).ReadString('\n')
+			notified <- strings.TrimSpace(line)
+		}()
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"%s: waiting for readiness of %s aborted, reason -> %w",
+				errLoc, d.AppName, ctx.Err(),
+			)
+		case msg := <-notified:
+			switch {
+			case msg == readyMsg:
+				return nil
+			case msg == busyMsg:
+				return fmt.Errorf(
+					"%s: %s failed to lock PID file, reason -> %w",
+					errLoc, d.AppName, ErrAlreadyRunning,
+				)
+			case strings.HasPrefix(msg, failedMsg):
+				return fmt.Errorf(
+					"%s: %w, reason -> %s", errLoc, ErrStartFailed,
+					strings.TrimSpace(strings.TrimPrefix(msg, failedMsg)),
+				)
+			}
+			// pipe is closed without notification,
+			// so only exit or timeout can be waited for
+			notified = nil
+		case err := <-exited:
+			return fmt.Errorf(
+				"%s: %s running failed, reason -> %w",
+				errLoc, d.AppName, newExitError(err),
+			)
+		case <-timer.C:
+			if d.RequireReady {
+				return fmt.Errorf(
+					"%s: %s did not notify its readiness in %s, reason -> %w",
+					errLoc, d.AppName, timeout, ErrStartTimeout,
+				)
+			}
+			return nil
+		}
+	}
+}
+
+// Takes readiness notification pipe inherited by daemonized process,
+// so it will not be passed to further child processes.
+func (d *Daemon) takeInheritedReadyPipe() {
+	name := d.readyEnvVarName()
+	fd, err := strconv.Atoi(os.Getenv(name))
+	os.Unsetenv(name)
+	if err != nil || fd < 3 {
+		return
+	}
+	syscall.CloseOnExec(fd)
+	d.readyFile = os.NewFile(uintptr(fd), "daemonigo ready pipe")
+}
+
+// Writes message into readiness notification pipe and closes it.
+// Does nothing if process was not started with notification pipe
+// or notification was already sent.
+// Closed pipe is not an error, as starting process may have already
+// exited (e.g. on start timeout) and there is no one to notify.
+func (d *Daemon) notifyParent(msg string) error {
+	d.mu.Lock()
+	file := d.readyFile
+	d.readyFile = nil
+	d.mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	_, err := fmt.Fprintln(file, msg)
+	if errors.Is(err, syscall.EPIPE) {
+		return nil
+	}
+	return err
+}
+
+// Notifies process which started daemon that
+// daemonized process has been initialized successfully.
+// Must be called in daemonized process only once,
+// all subsequent calls do nothing.
+//
+// If process is run by systemd with notification support,
+// then READY=1 is also sent to it (see notify package).
+// OnReady hook is called after notifications are sent.
+func Ready() error {
+	return stdDaemon().Ready()
+}
+
+// Notifies process which started daemon that
+// daemonized process has been initialized successfully.
+// Must be called in daemonized process only once,
+// all subsequent calls do nothing.
+//
+// If process is run by systemd with notification support,
+// then READY=1 is also sent to it (see notify package).
+// OnReady hook is called after notifications are sent.
+func (d *Daemon) Ready() error {
+	const errLoc = "daemonigo.Ready()"
+	// failure to notify parent process doesn't make daemon not ready,
+	// so it is reported only after systemd and hook are notified
+	parentErr := d.notifyParent(readyMsg)
+	if _, err := notify.Send(notify.Ready); err != nil {
+		return fmt.Errorf("%s: %w", errLoc, err)
+	}
+	if d.Hooks.OnReady != nil {
+		d.Hooks.OnReady()
+	}
+	if parentErr != nil {
+		return fmt.Errorf(
+			"%s: failed to notify parent process, reason -> %w",
+			errLoc, parentErr,
+		)
+	}
+	return nil
+}
+
+// Notifies process which started daemon that
+// daemonized process has failed to initialize because of given error.
+// Error message is passed to starting process and printed there.
+// Must be called in daemonized process instead of Ready().
+//
+// If process is run by systemd with notification support,
+// then error message is also sent to it as STATUS.
+func NotifyFailed(e error) error {
+	return stdDaemon().NotifyFailed(e)
+}
+
+// Notifies process which started daemon that
+// daemonized process has failed to initialize because of given error.
+// Error message is passed to starting process and printed there.
+// Must be called in daemonized process instead of Ready().
+//
+// If process is run by systemd with notification support,
+// then error message is also sent to it as STATUS.
+func (d *Daemon) NotifyFailed(e error) error {
+	const errLoc = "daemonigo.NotifyFailed()"
+	msg := failedMsg + " " + strings.Join(strings.Fields(e.Error()), " ")
+	if errors.Is(e, ErrAlreadyRunning) {
+		msg = busyMsg
+	}
+	if err := d.notifyParent(msg); err != nil {
+		return fmt.Errorf(
+			"%s: failed to notify parent process, reason -> %w", errLoc, err,
+		)
+	}
+	if _, err := notify.Send(notify.Status(e.Error())); err != nil {
+		return fmt.Errorf("%s: %w", errLoc, err)
+	}
+	return nil
+}
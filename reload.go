@@ -0,0 +1,258 @@
+package daemonigo
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/tyranron/daemonigo/notify"
+)
+
+// Name of environment variable which holds file descriptor
+// of locked PID file passed to daemonized process on reload.
+func (d *Daemon) pidFileEnvVarName() string {
+	return d.EnvVarName + "_PID_FD"
+}
+
+// Takes locked PID file inherited by daemonized process from its
+// predecessor. Returns nil if there is no such file.
+//
+// File lock belongs to open file description, which is shared between
+// predecessor and current process, so the lock is not released
+// during reload at all.
+func (d *Daemon) takeInheritedPidFile() *os.File {
+	name := d.pidFileEnvVarName()
+	fd, err := strconv.Atoi(os.Getenv(name))
+	os.Unsetenv(name)
+	if err != nil || fd < 3 {
+		return nil
+	}
+	syscall.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), d.PidFile)
+}
+
+// Starts watching ReloadSignal in daemonized process
+// to perform Reload() on receiving it.
+func (d *Daemon) watchReload() {
+	if d.ReloadSignal == nil {
+		return
+	}
+	d.HandleSignal(d.ReloadSignal, func(os.Signal) {
+		if err := d.Reload(); err != nil {
+			log.Println(err.Error())
+		}
+	})
+}
+
+// Returns channel which is closed when daemonized process
+// has been replaced by its successor during reload.
+// After that current process must stop serving and exit gracefully.
+func Replaced() <-chan struct{} {
+	return stdDaemon().Replaced()
+}
+
+// Returns channel which is closed when daemonized process
+// has been replaced by its successor during reload.
+// After that current process must stop serving and exit gracefully.
+func (d *Daemon) Replaced() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.replaced == nil {
+		d.replaced = make(chan struct{})
+	}
+	return d.replaced
+}
+
+// Performs zero-downtime reload of daemonized process.
+//
+// Starts new daemonized process from AppPath and passes to it
+// all listeners registered by Listen() and locked PID file.
+// Waits StartTimeout for readiness notification of new process
+// (see Ready()), and if it succeeds then closes Replaced() channel,
+// so current process can finish its work.
+// If new process fails, then current process keeps being the daemon.
+//
+// Usually there is no need to call it directly, as daemonized process
+// performs reload on receiving ReloadSignal.
+func Reload() error {
+	return stdDaemon().Reload()
+}
+
+// Performs zero-downtime reload of daemonized process.
+//
+// Starts new daemonized process from AppPath and passes to it
+// all listeners registered by Listen() and locked PID file.
+// Waits StartTimeout for readiness notification of new process
+// (see Ready()), and if it succeeds then closes Replaced() channel,
+// so current process can finish its work.
+// If new process fails, then current process keeps being the daemon.
+//
+// Usually there is no need to call it directly, as daemonized process
+// performs reload on receiving ReloadSignal.
+func (d *Daemon) Reload() error {
+	const errLoc = "daemonigo.Reload()"
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+	if d.pidFile == nil {
+		return fmt.Errorf(
+			"%s: current process does not hold PID file of %s",
+			errLoc, d.AppName,
+		)
+	}
+	notify.Send(notify.Reloading)
+
+	cmd, err := d.StartCommand()
+	if err != nil {
+		notify.Send(notify.Ready)
+		return fmt.Errorf(
+			"%s: failed to create daemon start command, reason -> %w",
+			errLoc, err,
+		)
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, d.pidFile)
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("%s=%d", d.pidFileEnvVarName(), 2+len(cmd.ExtraFiles)),
+		fmt.Sprintf("%s=%s", d.actionEnvVarName(), "reload"),
+	)
+	d.mu.Lock()
+	listeners := make([]fileConn, 0, len(d.listeners))
+	names := make([]string, 0, len(d.listeners))
+	for name, l := range d.listeners {
+		listeners = append(listeners, l)
+		names = append(names, name)
+	}
+	d.mu.Unlock()
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for i, l := range listeners {
+		file, err := l.File()
+		if err != nil {
+			d.takeReadyPipe(cmd).close()
+			notify.Send(notify.Ready)
+			return fmt.Errorf(
+				"%s: failed to get file of listener %q, reason -> %w",
+				errLoc, names[i], err,
+			)
+		}
+		files = append(files, file)
+	}
+	d.passListeners(cmd, names, files)
+
+	if err = cmd.Start(); err != nil {
+		d.takeReadyPipe(cmd).close()
+		notify.Send(notify.Ready)
+		return fmt.Errorf(
+			"%s: failed to start new %s process, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	if err = d.WaitReady(cmd, d.StartTimeout); err != nil {
+		cmd.Process.Kill()
+		notify.Send(notify.Ready)
+		return fmt.Errorf(
+			"%s: new %s process failed, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	// new process is published in PID file only after it is ready,
+	// so ReloadProcess() and other actions never see it earlier
+	if err = d.writePid(d.pidFile, cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		d.writePid(d.pidFile, os.Getpid())
+		notify.Send(notify.Ready)
+		return fmt.Errorf(
+			"%s: failed to write PID of new %s process, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+
+	// Unix socket files are owned by new process from now.
+	for _, l := range listeners {
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	d.stopControl()
+	notify.Send(notify.MainPID(cmd.Process.Pid), notify.Ready)
+	// File must not be unlocked, because lock is shared with new process.
+	d.pidFile.Close()
+	d.pidFile = nil
+	d.Replaced()
+	d.mu.Lock()
+	close(d.replaced)
+	d.mu.Unlock()
+	return nil
+}
+
+// Reloads daemon process, which is running now.
+// Sends ReloadSignal to daemonized process and waits ReloadTimeout
+// for the new daemonized process to take its place, which happens
+// only after current process accepts its readiness (see Reload()).
+//
+// This function can also be used when writing your own daemon actions.
+func ReloadProcess(process *os.Process) error {
+	return stdDaemon().ReloadProcess(process)
+}
+
+// Reloads daemon process, which is running now.
+// Sends ReloadSignal to daemonized process and waits ReloadTimeout
+// for the new daemonized process to take its place, which happens
+// only after current process accepts its readiness (see Reload()).
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) ReloadProcess(process *os.Process) error {
+	const errLoc = "daemonigo.ReloadProcess()"
+	if d.ReloadSignal == nil {
+		return fmt.Errorf("%s: reloading of %s is disabled", errLoc, d.AppName)
+	}
+	if err := process.Signal(d.ReloadSignal); err != nil {
+		return fmt.Errorf(
+			"%s: failed to send reload signal to %s, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	// in supervised mode supervisor keeps running and restarts worker
+	pid, workerPid := process.Pid, 0
+	if d.Supervise {
+		if state, err := d.Supervision(); err == nil && state != nil {
+			workerPid = state.WorkerPid
+		}
+	}
+	deadline := time.Now().Add(d.ReloadTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+		switch isRunning, pr, err := d.Status(); {
+		case err != nil:
+			return fmt.Errorf(
+				"%s: checking status of %s failed, reason -> %w",
+				errLoc, d.AppName, err,
+			)
+		case !isRunning:
+			return fmt.Errorf(
+				"%s: %s stopped during reload, reason -> %w",
+				errLoc, d.AppName, ErrNotRunning,
+			)
+		case !d.Supervise && pr.Pid != pid:
+			return nil
+		case d.Supervise:
+			state, err := d.Supervision()
+			if err == nil && state != nil &&
+				state.State == SupervisorRunning &&
+				state.WorkerPid != workerPid {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf(
+		"%s: %s has not been replaced in %s, see application logs",
+		errLoc, d.AppName, d.ReloadTimeout,
+	)
+}
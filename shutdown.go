@@ -0,0 +1,231 @@
+package daemonigo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tyranron/daemonigo/notify"
+)
+
+// Function performing single step of daemon shutdown.
+// It must return when given context is done.
+type ShutdownFunc func(ctx context.Context) error
+
+// Step of daemon shutdown registered by RegisterShutdown().
+type shutdownStep struct {
+	name     string
+	priority int
+	timeout  time.Duration
+	fn       ShutdownFunc
+}
+
+// Registers function with given name to be called on shutdown
+// of daemonized process (see Shutdown()).
+//
+// Functions are called one by one in ascending order of priority,
+// and in order of registration for equal priorities. Each function
+// is given a context, which is done after given timeout passes
+// (zero means no own limit) or ShutdownTimeout of the whole shutdown
+// expires. Shutdown proceeds to the next function after the context
+// is done, even if function has not returned yet.
+func RegisterShutdown(
+	name string, priority int, timeout time.Duration, fn ShutdownFunc,
+) {
+	stdDaemon().RegisterShutdown(name, priority, timeout, fn)
+}
+
+// Registers function with given name to be called on shutdown
+// of daemonized process (see Shutdown()).
+//
+// Functions are called one by one in ascending order of priority,
+// and in order of registration for equal priorities. Each function
+// is given a context, which is done after given timeout passes
+// (zero means no own limit) or ShutdownTimeout of the whole shutdown
+// expires. Shutdown proceeds to the next function after the context
+// is done, even if function has not returned yet.
+func (d *Daemon) RegisterShutdown(
+	name string, priority int, timeout time.Duration, fn ShutdownFunc,
+) {
+	if fn == nil {
+		panic("daemonigo.RegisterShutdown(): function cannot be nil")
+	}
+	d.mu.Lock()
+	d.shutdownSteps = append(d.shutdownSteps, shutdownStep{
+		name: name, priority: priority, timeout: timeout, fn: fn,
+	})
+	d.mu.Unlock()
+	d.watchShutdown()
+}
+
+// Starts watching signals and reload of daemonized process,
+// which trigger its shutdown. Only the first call has effect.
+//
+// Shutdown is triggered by signal of the first stop step
+// (see StopPolicy) and SIGTERM, unless their handling has been
+// overridden by HandleSignal(), and by replacing daemonized process
+// with its successor on reload (see Replaced()).
+func (d *Daemon) watchShutdown() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.shutdownDone != nil {
+		return
+	}
+	d.shutdownDone = make(chan struct{})
+	stopSignals := []os.Signal{d.StopPolicy.firstSignal(), syscall.SIGTERM}
+	for _, sig := range stopSignals {
+		if _, overridden := d.signalHandlers[sig]; !overridden {
+			d.handleSignal(sig, func(os.Signal) {
+				d.Shutdown()
+			})
+		}
+	}
+	go func() {
+		<-d.Replaced()
+		d.Shutdown()
+	}()
+}
+
+// Starts shutdown of daemonized process, unless it is already started.
+//
+// Shutdown notifies systemd (see notify package), calls OnShutdown hook
+// and then functions registered by RegisterShutdown().
+// If nobody waits for shutdown with Wait(), then process exits
+// after shutdown finishes.
+func Shutdown() {
+	stdDaemon().Shutdown()
+}
+
+// Starts shutdown of daemonized process, unless it is already started.
+//
+// Shutdown notifies systemd (see notify package), calls OnShutdown hook
+// and then functions registered by RegisterShutdown().
+// If nobody waits for shutdown with Wait(), then process exits
+// after shutdown finishes.
+func (d *Daemon) Shutdown() {
+	d.watchShutdown()
+	d.shutdownOnce.Do(func() {
+		go d.shutdown()
+	})
+}
+
+// Performs shutdown of daemonized process.
+func (d *Daemon) shutdown() {
+	const errLoc = "daemonigo.Shutdown()"
+	notify.Send(notify.Stopping)
+	d.mu.Lock()
+	steps := make([]shutdownStep, 0, len(d.shutdownSteps)+1)
+	if hook := d.Hooks.OnShutdown; hook != nil {
+		steps = append(steps, shutdownStep{
+			name: "OnShutdown hook",
+			fn: func(context.Context) error {
+				hook()
+				return nil
+			},
+		})
+	}
+	steps = append(steps, d.shutdownSteps...)
+	d.mu.Unlock()
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].priority < steps[j].priority
+	})
+
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if d.ShutdownTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.ShutdownTimeout)
+	}
+	defer cancel()
+	var failures []string
+	for _, step := range steps {
+		if ctx.Err() != nil {
+			failures = append(failures, fmt.Sprintf("%q skipped", step.name))
+			continue
+		}
+		if err := step.run(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%q %s", step.name, err))
+		}
+	}
+
+	var err error
+	switch {
+	case ctx.Err() != nil:
+		err = fmt.Errorf("%s: %w after %s, reason -> %s",
+			errLoc, ErrShutdownTimeout, d.ShutdownTimeout,
+			strings.Join(failures, "; "),
+		)
+	case len(failures) > 0:
+		err = fmt.Errorf("%s: %w, reason -> %s",
+			errLoc, ErrShutdownFailed, strings.Join(failures, "; "),
+		)
+	}
+	d.mu.Lock()
+	d.shutdownErr = err
+	waiters := d.shutdownWaiters
+	close(d.shutdownDone)
+	d.mu.Unlock()
+	if waiters > 0 {
+		return
+	}
+	if err != nil {
+		log.Println(err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Runs shutdown step within its timeout and given context.
+func (s shutdownStep) run(ctx context.Context) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed, reason -> %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("has not finished, reason -> %w", ctx.Err())
+	}
+}
+
+// Blocks until shutdown of daemonized process finishes
+// (see Shutdown()) and returns its error, which matches
+// ErrShutdownTimeout if ShutdownTimeout has expired,
+// or ErrShutdownFailed if some of shutdown functions have failed.
+//
+// Process does not exit after shutdown while somebody waits for it,
+// so it can be used as the last statement of main() function.
+func Wait() error {
+	return stdDaemon().Wait()
+}
+
+// Blocks until shutdown of daemonized process finishes
+// (see Shutdown()) and returns its error, which matches
+// ErrShutdownTimeout if ShutdownTimeout has expired,
+// or ErrShutdownFailed if some of shutdown functions have failed.
+//
+// Process does not exit after shutdown while somebody waits for it,
+// so it can be used as the last statement of main() function.
+func (d *Daemon) Wait() error {
+	d.watchShutdown()
+	d.mu.Lock()
+	d.shutdownWaiters++
+	done := d.shutdownDone
+	d.mu.Unlock()
+	<-done
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.shutdownErr
+}
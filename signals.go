@@ -0,0 +1,69 @@
+package daemonigo
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"runtime/pprof"
+)
+
+// Handler of signal received by daemonized process.
+type SignalHandler func(sig os.Signal)
+
+// Registers handler of given signal in daemonized process
+// or overrides previous. Each received signal is handled
+// in its own goroutine.
+//
+// Daemonized process handles ReloadSignal, ReopenSignal and stop signals
+// (see Shutdown()) this way, so their handling can be overridden.
+func HandleSignal(sig os.Signal, handler SignalHandler) {
+	stdDaemon().HandleSignal(sig, handler)
+}
+
+// Registers handler of given signal in daemonized process
+// or overrides previous. Each received signal is handled
+// in its own goroutine.
+//
+// Daemonized process handles ReloadSignal, ReopenSignal and stop signals
+// (see Shutdown()) this way, so their handling can be overridden.
+func (d *Daemon) HandleSignal(sig os.Signal, handler SignalHandler) {
+	if sig == nil {
+		panic("daemonigo.HandleSignal(): signal cannot be nil")
+	}
+	if handler == nil {
+		panic("daemonigo.HandleSignal(): handler cannot be nil")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handleSignal(sig, handler)
+}
+
+// Registers handler of given signal. Must be called with mu locked.
+func (d *Daemon) handleSignal(sig os.Signal, handler SignalHandler) {
+	if d.signalHandlers == nil {
+		d.signalHandlers = make(map[os.Signal]SignalHandler)
+		d.signals = make(chan os.Signal, 8)
+		go d.routeSignals()
+	}
+	d.signalHandlers[sig] = handler
+	signal.Notify(d.signals, sig)
+}
+
+// Passes received signals to their handlers.
+func (d *Daemon) routeSignals() {
+	for sig := range d.signals {
+		d.mu.Lock()
+		handler := d.signalHandlers[sig]
+		d.mu.Unlock()
+		if handler != nil {
+			go handler(sig)
+		}
+	}
+}
+
+// Signal handler, which writes stacks of all goroutines of process
+// into its standard error, so state of hung daemon can be inspected.
+func DumpGoroutines(sig os.Signal) {
+	log.Printf("daemonigo: received %s signal, dumping goroutines", sig)
+	pprof.Lookup("goroutine").WriteTo(os.Stderr, 2)
+}
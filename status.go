@@ -0,0 +1,185 @@
+package daemonigo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// States of daemon reported by StatusDetailed().
+const (
+	// Daemon is running.
+	StateRunning = "running"
+	// Daemon is not running and has been stopped normally.
+	StateStopped = "stopped"
+	// Daemon is not running, but its PID file exists,
+	// so it has exited without being stopped.
+	StateDead = "dead"
+	// Daemon is not running, because its supervisor has given up
+	// restarting crashed worker process.
+	StateFailed = "failed"
+	// PID file is locked by unexpected process (see ErrUnexpectedProcess).
+	StateUnexpected = "unexpected"
+)
+
+// Detailed status of daemon.
+type StatusInfo struct {
+	// State of daemon, one of StateRunning, StateStopped, StateDead,
+	// StateFailed or StateUnexpected.
+	State string `json:"state"`
+
+	// PID of daemon process, if it is running.
+	PID int `json:"pid,omitempty"`
+
+	// Time when daemon process has been started, if it is running.
+	StartedAt time.Time `json:"started_at,omitempty"`
+
+	// Duration of daemon process running.
+	Uptime time.Duration `json:"-"`
+
+	// Absolute path to executable of daemon process.
+	Executable string `json:"executable"`
+
+	// Absolute path to PID file.
+	PidFile string `json:"pid_file"`
+
+	// Number of worker process restarts in supervised mode.
+	Restarts int `json:"restarts"`
+
+	// Exit status of the last exited worker process in supervised mode.
+	LastExit string `json:"last_exit,omitempty"`
+
+	// Reason of StateUnexpected state.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Encodes status into JSON with uptime in seconds.
+func (s *StatusInfo) MarshalJSON() ([]byte, error) {
+	type info StatusInfo
+	v := struct {
+		*info
+		StartedAt *time.Time `json:"started_at,omitempty"`
+		Uptime    int64      `json:"uptime_seconds"`
+	}{info: (*info)(s), Uptime: int64(s.Uptime / time.Second)}
+	if !s.StartedAt.IsZero() {
+		v.StartedAt = &s.StartedAt
+	}
+	return json.Marshal(v)
+}
+
+// Returns ActionError with LSB exit code of "status" action,
+// which corresponds to state of daemon, or nil if it is running.
+func (s *StatusInfo) exitError() error {
+	switch s.State {
+	case StateRunning:
+		return nil
+	case StateDead, StateFailed:
+		return newActionError(ExitStatusDead, fmt.Errorf(
+			"daemon is %s, reason -> %w", s.State, ErrNotRunning,
+		))
+	case StateStopped:
+		return newActionError(ExitStatusNotRunning, ErrNotRunning)
+	default:
+		return newActionError(ExitStatusUnknown, fmt.Errorf(
+			"%w: %s", ErrUnexpectedProcess, s.Reason,
+		))
+	}
+}
+
+// Returns detailed status of daemon.
+// Can be used in daemon actions to operate with daemonized process.
+func StatusDetailed() (*StatusInfo, error) {
+	return stdDaemon().StatusDetailed()
+}
+
+// Returns detailed status of daemon.
+// Can be used in daemon actions to operate with daemonized process.
+func (d *Daemon) StatusDetailed() (*StatusInfo, error) {
+	const errLoc = "daemonigo.StatusDetailed()"
+	info := &StatusInfo{Executable: d.executable(), PidFile: d.PidFile}
+	if path, err := filepath.Abs(d.PidFile); err == nil {
+		info.PidFile = path
+	}
+	if d.Supervise {
+		state, err := d.Supervision()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", errLoc, err)
+		}
+		if state != nil {
+			info.Restarts = state.Restarts
+			info.LastExit = state.LastExit
+			if state.State == SupervisorFailed {
+				info.State = StateFailed
+			}
+		}
+	}
+
+	isRunning, process, err := d.Status()
+	switch {
+	case errors.Is(err, ErrUnexpectedProcess):
+		info.State = StateUnexpected
+		info.Reason = err.Error()
+		return info, nil
+	case err != nil:
+		return nil, fmt.Errorf("%s: %w", errLoc, err)
+	case !isRunning:
+		if info.State != StateFailed {
+			info.State = StateStopped
+			if stat, err := os.Stat(d.PidFile); err == nil && stat.Size() > 0 {
+				info.State = StateDead
+			}
+		}
+		return info, nil
+	}
+
+	info.State = StateRunning
+	info.PID = process.Pid
+	if exe, err := processExe(process.Pid); err == nil && exe != "" {
+		info.Executable = exe
+	}
+	// start time of process is recorded in PID file, while its
+	// modification time changes whenever it is rewritten (e.g. on reload)
+	if content, err := os.ReadFile(d.PidFile); err == nil {
+		if _, id, err := parsePidFile(string(content)); err == nil {
+			info.StartedAt = processStartedAt(id.startTime)
+		}
+	}
+	if info.StartedAt.IsZero() {
+		// PID file is written by daemon process right after its start
+		if stat, err := os.Stat(d.PidFile); err == nil {
+			info.StartedAt = stat.ModTime()
+		}
+	}
+	if !info.StartedAt.IsZero() {
+		info.Uptime = time.Since(info.StartedAt)
+	}
+	return info, nil
+}
+
+// Clears PID file of stopped daemon, so it is distinguished
+// from daemon which has exited without being stopped.
+// PID file is truncated under lock instead of removing,
+// so it is never removed while another daemon holds it.
+func (d *Daemon) clearPidFile() error {
+	file, err := os.OpenFile(d.PidFile, os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	fd := int(file.Fd())
+	if err = syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			return nil
+		}
+		return err
+	}
+	defer syscall.Flock(fd, syscall.LOCK_UN)
+	return file.Truncate(0)
+}
@@ -0,0 +1,194 @@
+package daemonigo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Interval of checking status of daemon process while it is stopping.
+const stopPollInterval = 200 * time.Millisecond
+
+// Describes one step of stopping daemon process.
+type StopStep struct {
+	// Signal to be sent to daemonized process.
+	Signal os.Signal
+
+	// Time to wait for daemonized process to stop after sending signal.
+	// Zero value means waiting without limit.
+	Timeout time.Duration
+}
+
+// Describes how daemon process is stopped.
+//
+// Signals of steps are sent one by one, while daemonized process
+// keeps running after timeout of previous step passes.
+// The last step usually sends SIGKILL, so hung daemon is stopped anyway.
+type StopPolicy struct {
+	// Steps of stopping daemon process.
+	Steps []StopStep
+
+	// If set, the whole process group of daemonized process is killed
+	// with SIGKILL after all steps are passed, so its child processes
+	// are stopped too.
+	KillGroup bool
+
+	// Time to wait for daemonized process to stop after killing
+	// its process group. Zero value means waiting without limit.
+	KillTimeout time.Duration
+}
+
+// Stops daemon process according to StopPolicy.
+//
+// This function can also be used when writing your own daemon actions.
+func Stop(process *os.Process) (e error) {
+	return stdDaemon().Stop(process)
+}
+
+// Stops daemon process according to StopPolicy.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) Stop(process *os.Process) (e error) {
+	return d.StopContext(context.Background(), process)
+}
+
+// Stops daemon process according to StopPolicy.
+// Stopping is aborted when given context is done.
+//
+// This function can also be used when writing your own daemon actions.
+func StopContext(ctx context.Context, process *os.Process) error {
+	return stdDaemon().StopContext(ctx, process)
+}
+
+// Stops daemon process according to StopPolicy.
+// Stopping is aborted when given context is done.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) StopContext(ctx context.Context, process *os.Process) error {
+	const errLoc = "daemonigo.StopContext()"
+	h := d.openProcess(process)
+	defer h.close()
+	for _, step := range d.StopPolicy.Steps {
+		if d.Supervise && step.Signal == syscall.SIGKILL {
+			// killed supervisor cannot stop its worker process,
+			// so the whole process group is killed instead
+			err := syscall.Kill(-process.Pid, syscall.SIGKILL)
+			if err != nil && err != syscall.ESRCH {
+				return fmt.Errorf(
+					"%s: failed to kill process group of %s, reason -> %w",
+					errLoc, d.AppName, err,
+				)
+			}
+		}
+		if err := h.signal(step.Signal); err != nil {
+			if err == os.ErrProcessDone {
+				return d.stopped()
+			}
+			return fmt.Errorf(
+				"%s: failed to send %s signal to %s, reason -> %w",
+				errLoc, step.Signal, d.AppName, err,
+			)
+		}
+		switch stopped, err := h.wait(ctx, step.Timeout); {
+		case err != nil:
+			return fmt.Errorf("%s: %w", errLoc, err)
+		case stopped:
+			return d.stopped()
+		}
+	}
+	if d.StopPolicy.KillGroup {
+		err := syscall.Kill(-process.Pid, syscall.SIGKILL)
+		if err != nil && err != syscall.ESRCH {
+			return fmt.Errorf(
+				"%s: failed to kill process group of %s, reason -> %w",
+				errLoc, d.AppName, err,
+			)
+		}
+		switch stopped, err := h.wait(ctx, d.StopPolicy.KillTimeout); {
+		case err != nil:
+			return fmt.Errorf("%s: %w", errLoc, err)
+		case stopped:
+			return d.stopped()
+		}
+	}
+	return fmt.Errorf(
+		"%s: %s with PID %d is still running after all stop steps, "+
+			"reason -> %w", errLoc, d.AppName, process.Pid, ErrStopTimeout,
+	)
+}
+
+// Finishes stopping of daemon process.
+func (d *Daemon) stopped() error {
+	if err := d.clearPidFile(); err != nil {
+		return fmt.Errorf(
+			"daemonigo.StopContext(): failed to clear PID file, reason -> %w",
+			err,
+		)
+	}
+	return nil
+}
+
+// Waits for daemon process to stop during given timeout.
+// Zero timeout means waiting until context is done.
+func (d *Daemon) waitStopped(
+	ctx context.Context, timeout time.Duration,
+) (stopped bool, e error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf(
+				"stopping of %s aborted, reason -> %w",
+				d.AppName, ctx.Err(),
+			)
+		case <-deadline:
+			return false, nil
+		case <-ticker.C:
+		}
+		switch isRunning, _, err := d.Status(); {
+		case err != nil:
+			return false, fmt.Errorf(
+				"checking status of %s failed, reason -> %w",
+				d.AppName, err,
+			)
+		case !isRunning:
+			return true, nil
+		}
+	}
+}
+
+// Returns total time of waiting for daemon process to stop,
+// or zero if waiting is not limited.
+func (p StopPolicy) timeout() (total time.Duration) {
+	for _, step := range p.Steps {
+		if step.Timeout <= 0 {
+			return 0
+		}
+		total += step.Timeout
+	}
+	if p.KillGroup {
+		if p.KillTimeout <= 0 {
+			return 0
+		}
+		total += p.KillTimeout
+	}
+	return
+}
+
+// Returns signal of the first stop step,
+// or os.Interrupt if there are no steps.
+func (p StopPolicy) firstSignal() os.Signal {
+	if len(p.Steps) == 0 || p.Steps[0].Signal == nil {
+		return os.Interrupt
+	}
+	return p.Steps[0].Signal
+}
@@ -0,0 +1,317 @@
+package daemonigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// States of supervisor process.
+const (
+	// Worker process is running.
+	SupervisorRunning = "running"
+	// Worker process has crashed and is waiting to be restarted.
+	SupervisorBackoff = "backoff"
+	// Worker process has crashed too many times and is not restarted.
+	SupervisorFailed = "failed"
+	// Worker process has exited normally or has been stopped.
+	SupervisorStopped = "stopped"
+)
+
+// Describes how worker process is restarted in supervised mode.
+type RestartPolicy struct {
+	// Delay before restarting crashed worker process.
+	// It is doubled on each consecutive crash up to MaxDelay.
+	Delay time.Duration
+
+	// Maximum delay before restarting crashed worker process.
+	// Zero value means no limit.
+	MaxDelay time.Duration
+
+	// Maximum number of restarts during Window. If worker process
+	// crashes more often, supervisor goes into "failed" state and exits.
+	// Zero value means unlimited restarts.
+	MaxRestarts int
+
+	// Period of counting restarts. Worker process running longer than
+	// Window is considered stable, so restart delay is reset.
+	// Zero value means counting all restarts and never resetting delay.
+	Window time.Duration
+}
+
+// Returns delay before restart after given number of consecutive crashes.
+func (p RestartPolicy) delay(crashes int) time.Duration {
+	limit := p.MaxDelay
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	delay := p.Delay
+	for i := 1; i < crashes && delay > 0 && delay < limit; i++ {
+		if delay > limit/2 {
+			delay = limit
+		} else {
+			delay *= 2
+		}
+	}
+	if delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// State of supervisor process, which is stored in state file
+// near PID file to be available for other processes.
+type SupervisorState struct {
+	// State of supervisor process,
+	// one of SupervisorRunning, SupervisorBackoff,
+	// SupervisorFailed or SupervisorStopped.
+	State string `json:"state"`
+
+	// PID of current worker process.
+	WorkerPid int `json:"worker_pid,omitempty"`
+
+	// Number of worker process restarts after crashes.
+	Restarts int `json:"restarts"`
+
+	// Exit status of the last exited worker process.
+	LastExit string `json:"last_exit,omitempty"`
+
+	// Time when state has been changed.
+	Updated time.Time `json:"updated"`
+}
+
+// Returns path to file with given extension,
+// placed next to PID file and named after it.
+func (d *Daemon) pidFileSibling(ext string) string {
+	return strings.TrimSuffix(d.PidFile, filepath.Ext(d.PidFile)) + ext
+}
+
+// Name of environment variable used to distinguish
+// supervisor and worker processes.
+func (d *Daemon) workerEnvVarName() string {
+	return d.EnvVarName + "_WORKER"
+}
+
+// Checks if current process is a worker process of supervisor
+// and removes the mark from environment.
+func (d *Daemon) takeWorkerMark() bool {
+	name := d.workerEnvVarName()
+	isWorker := os.Getenv(name) == d.EnvVarValue
+	os.Unsetenv(name)
+	return isWorker
+}
+
+// Saves state of supervisor process into state file.
+// Writing is atomic, so readers never see partially written state.
+func (d *Daemon) saveSupervisorState(state *SupervisorState) error {
+	state.Updated = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	path := d.pidFileSibling(".state")
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, d.PidFileMask); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Returns state of supervisor process in supervised mode.
+// Returns nil state if supervisor has never been run.
+//
+// This function can also be used when writing your own daemon actions.
+func Supervision() (*SupervisorState, error) {
+	return stdDaemon().Supervision()
+}
+
+// Returns state of supervisor process in supervised mode.
+// Returns nil state if supervisor has never been run.
+//
+// This method can also be used when writing your own daemon actions.
+func (d *Daemon) Supervision() (*SupervisorState, error) {
+	const errLoc = "daemonigo.Supervision()"
+	data, err := os.ReadFile(d.pidFileSibling(".state"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf(
+			"%s: could not read state file, reason -> %w",
+			errLoc, err,
+		)
+	}
+	state := &SupervisorState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf(
+			"%s: bad state file format, reason -> %w", errLoc, err,
+		)
+	}
+	return state, nil
+}
+
+// Prepares command for starting worker process.
+// Worker process inherits standard output and standard error
+// of supervisor, and its readiness notification pipe if any.
+// Extra files of command must be closed after its start.
+func (d *Daemon) workerCommand() (*exec.Cmd, error) {
+	path, err := filepath.Abs(d.AppPath)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(path, d.daemonArgs()...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	setParentDeathSignal(cmd)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", d.EnvVarName, d.EnvVarValue),
+		fmt.Sprintf("%s=%s", d.workerEnvVarName(), d.EnvVarValue),
+		fmt.Sprintf("%s=%s", d.actionEnvVarName(), d.action),
+	)
+	names, inherited, err := d.dupInherited()
+	if err != nil {
+		return nil, err
+	}
+	d.passListeners(cmd, names, inherited)
+	d.mu.Lock()
+	ready := d.readyFile
+	d.readyFile = nil
+	d.mu.Unlock()
+	if ready != nil {
+		// only the first worker notifies starting process
+		cmd.ExtraFiles = append(cmd.ExtraFiles, ready)
+		cmd.Env = append(cmd.Env, fmt.Sprintf(
+			"%s=%d", d.readyEnvVarName(), 2+len(cmd.ExtraFiles),
+		))
+	}
+	return cmd, nil
+}
+
+// Runs worker processes and restarts them on abnormal exit
+// according to RestartPolicy, until supervisor is stopped
+// or worker process exits normally.
+func (d *Daemon) supervise() error {
+	const errLoc = "daemonigo.supervise()"
+	stopSignals := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	for _, step := range d.StopPolicy.Steps {
+		stopSignals = append(stopSignals, step.Signal)
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, stopSignals...)
+	var reload chan os.Signal
+	if d.ReloadSignal != nil {
+		reload = make(chan os.Signal, 1)
+		signal.Notify(reload, d.ReloadSignal)
+	}
+
+	state := &SupervisorState{}
+	save := func(s string) {
+		state.State = s
+		if err := d.saveSupervisorState(state); err != nil {
+			fmt.Fprintf(os.Stderr,
+				"%s: failed to save state, reason -> %s\n", errLoc, err.Error(),
+			)
+		}
+	}
+	var (
+		crashes  int
+		restarts []time.Time
+	)
+	for {
+		cmd, err := d.workerCommand()
+		if err == nil {
+			err = cmd.Start()
+			for _, file := range cmd.ExtraFiles {
+				file.Close()
+			}
+		}
+		if err != nil {
+			save(SupervisorFailed)
+			return fmt.Errorf(
+				"%s: failed to start worker of %s, reason -> %w",
+				errLoc, d.AppName, err,
+			)
+		}
+		started := time.Now()
+		d.mu.Lock()
+		d.worker = cmd.Process
+		d.mu.Unlock()
+		state.WorkerPid = cmd.Process.Pid
+		save(SupervisorRunning)
+
+		exited := make(chan error, 1)
+		go func() {
+			exited <- cmd.Wait()
+		}()
+		stopping, reloading := false, false
+	waiting:
+		for {
+			select {
+			case sig := <-stop:
+				stopping = true
+				cmd.Process.Signal(sig)
+			case <-reload:
+				reloading = true
+				cmd.Process.Signal(d.StopPolicy.firstSignal())
+			case err = <-exited:
+				break waiting
+			}
+		}
+		d.mu.Lock()
+		d.worker = nil
+		d.mu.Unlock()
+		state.WorkerPid = 0
+		state.LastExit = "exit status 0"
+		if err != nil {
+			state.LastExit = err.Error()
+		}
+		switch {
+		case stopping || (err == nil && !reloading):
+			save(SupervisorStopped)
+			return nil
+		case reloading:
+			continue
+		}
+
+		now := time.Now()
+		if d.RestartPolicy.Window > 0 && now.Sub(started) > d.RestartPolicy.Window {
+			crashes = 0
+		}
+		crashes++
+		period := ""
+		if d.RestartPolicy.Window > 0 {
+			period = " in " + d.RestartPolicy.Window.String()
+			recent := restarts[:0]
+			for _, t := range restarts {
+				if now.Sub(t) < d.RestartPolicy.Window {
+					recent = append(recent, t)
+				}
+			}
+			restarts = recent
+		}
+		if d.RestartPolicy.MaxRestarts > 0 &&
+			len(restarts) >= d.RestartPolicy.MaxRestarts {
+			save(SupervisorFailed)
+			return fmt.Errorf(
+				"%s: worker of %s crashed %d times%s, last exit -> %s",
+				errLoc, d.AppName, len(restarts)+1, period, state.LastExit,
+			)
+		}
+		save(SupervisorBackoff)
+		select {
+		case <-stop:
+			save(SupervisorStopped)
+			return nil
+		case <-time.After(d.RestartPolicy.delay(crashes)):
+		}
+		restarts = append(restarts, time.Now())
+		state.Restarts++
+	}
+}
@@ -0,0 +1,36 @@
+package daemonigo
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyDelay(t *testing.T) {
+	backoff := RestartPolicy{Delay: time.Second, MaxDelay: 10 * time.Second}
+	for _, tc := range []struct {
+		policy   RestartPolicy
+		crashes  int
+		expected time.Duration
+	}{
+		{backoff, 0, time.Second},
+		{backoff, 1, time.Second},
+		{backoff, 2, 2 * time.Second},
+		{backoff, 3, 4 * time.Second},
+		{backoff, 4, 8 * time.Second},
+		{backoff, 5, 10 * time.Second},
+		{backoff, 100, 10 * time.Second},
+		{RestartPolicy{Delay: time.Second}, 5, 16 * time.Second},
+		{RestartPolicy{Delay: time.Second}, 100, math.MaxInt64},
+		{RestartPolicy{Delay: 3 * time.Second, MaxDelay: time.Second}, 1,
+			time.Second,
+		},
+		{RestartPolicy{MaxDelay: time.Minute}, 5, 0},
+	} {
+		if got := tc.policy.delay(tc.crashes); got != tc.expected {
+			t.Errorf("%+v.delay(%d) = %s; want %s",
+				tc.policy, tc.crashes, got, tc.expected,
+			)
+		}
+	}
+}
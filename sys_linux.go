@@ -0,0 +1,22 @@
+package daemonigo
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Duplicates file descriptor oldfd onto newfd.
+// Uses dup3(2), as dup2(2) is not available on all Linux architectures.
+func dup2(oldfd, newfd int) error {
+	return syscall.Dup3(oldfd, newfd, 0)
+}
+
+// Makes process of given command to be killed with SIGKILL when
+// its parent dies, so worker process does not outlive its supervisor
+// even when the latter is killed with SIGKILL.
+func setParentDeathSignal(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
+}
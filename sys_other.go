@@ -0,0 +1,17 @@
+//go:build !linux
+
+package daemonigo
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Duplicates file descriptor oldfd onto newfd.
+func dup2(oldfd, newfd int) error {
+	return syscall.Dup2(oldfd, newfd)
+}
+
+// Parent death signal is supported on Linux only, on other systems
+// worker process is stopped by killing process group of supervisor.
+func setParentDeathSignal(cmd *exec.Cmd) {}
@@ -0,0 +1,313 @@
+package daemonigo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Default directory for installing systemd unit files.
+const systemdUnitDir = "/etc/systemd/system"
+
+// Describes socket passed to daemon by systemd socket activation.
+type Socket struct {
+	// Name of listener passed to Listen() or ListenPacket().
+	Name string
+
+	// Network of socket: "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6",
+	// "unix", "unixgram" or "unixpacket".
+	Network string
+
+	// Address of socket in the same format as for Listen().
+	Address string
+}
+
+// Returns systemd directive announcing socket, like "ListenStream=:80".
+func (s Socket) directive() (string, error) {
+	address := s.Address
+	if strings.HasPrefix(address, ":") {
+		address = address[1:]
+	}
+	switch s.Network {
+	case "tcp", "tcp4", "tcp6":
+		return "ListenStream=" + address, nil
+	case "udp", "udp4", "udp6":
+		return "ListenDatagram=" + address, nil
+	case "unix":
+		return "ListenStream=" + s.Address, nil
+	case "unixgram":
+		return "ListenDatagram=" + s.Address, nil
+	case "unixpacket":
+		return "ListenSequentialPacket=" + s.Address, nil
+	}
+	return "", fmt.Errorf("unsupported network %q of socket", s.Network)
+}
+
+// Unit file of systemd.
+type SystemdUnit struct {
+	// File name of unit, like "daemon.service".
+	Name string
+
+	// Content of unit file.
+	Content string
+}
+
+// Template of systemd service unit.
+var serviceUnitTmpl = template.Must(template.New("service").Parse(
+	`[Unit]
+Description={{.Description}}
+After=network.target
+{{- range .Dependencies}}
+Wants={{.}}
+After={{.}}
+{{- end}}
+{{- range .Sockets}}
+Requires={{.}}
+After={{.}}
+{{- end}}
+{{- if .StartLimitBurst}}
+StartLimitIntervalSec={{.StartLimitInterval}}
+StartLimitBurst={{.StartLimitBurst}}
+{{- end}}
+
+[Service]
+Type={{.Type}}
+{{- if eq .Type "notify"}}
+NotifyAccess=all
+{{- end}}
+ExecStart={{.ExecStart}}
+ExecStop={{.ExecStop}}
+{{- if .ExecReload}}
+ExecReload={{.ExecReload}}
+{{- end}}
+PIDFile={{.PidFile}}
+WorkingDirectory={{.WorkDir}}
+UMask={{.Umask}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+{{- if .Group}}
+Group={{.Group}}
+{{- end}}
+{{- if .SupplementaryGroups}}
+SupplementaryGroups={{.SupplementaryGroups}}
+{{- end}}
+{{- if .StdoutFile}}
+StandardOutput=append:{{.StdoutFile}}
+{{- end}}
+{{- if .StderrFile}}
+StandardError=append:{{.StderrFile}}
+{{- end}}
+Restart=on-failure
+RestartSec={{.RestartSec}}
+TimeoutStopSec={{.TimeoutStopSec}}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// Template of systemd socket unit.
+var socketUnitTmpl = template.Must(template.New("socket").Parse(
+	`[Unit]
+Description={{.Description}}
+PartOf={{.Service}}
+
+[Socket]
+{{.Listen}}
+FileDescriptorName={{.Name}}
+Service={{.Service}}
+
+[Install]
+WantedBy=sockets.target
+`))
+
+// Returns systemd units of daemon: service unit running it with
+// default "run" action in foreground, and socket unit for every socket
+// of Sockets setting.
+//
+// Command line flags preceding action name are passed to daemon
+// in ExecStart along with ExtraArgs (see ArgsFilter).
+func SystemdUnits() ([]SystemdUnit, error) {
+	return stdDaemon().SystemdUnits()
+}
+
+// Returns systemd units of daemon: service unit running it with
+// default "run" action in foreground, and socket unit for every socket
+// of Sockets setting.
+//
+// Command line flags preceding action name are passed to daemon
+// in ExecStart along with ExtraArgs (see ArgsFilter).
+func (d *Daemon) SystemdUnits() ([]SystemdUnit, error) {
+	const errLoc = "daemonigo.SystemdUnits()"
+	description := d.Description
+	if description == "" {
+		description = d.AppName
+	}
+	service := d.AppName + ".service"
+
+	var (
+		units   []SystemdUnit
+		sockets []string
+	)
+	for _, s := range d.Sockets {
+		listen, err := s.directive()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q %w", errLoc, s.Name, err)
+		}
+		var buf bytes.Buffer
+		err = socketUnitTmpl.Execute(&buf, map[string]string{
+			"Description": description + " socket " + s.Name,
+			"Service":     service,
+			"Listen":      listen,
+			"Name":        s.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%s: failed to render socket unit, reason -> %w", errLoc, err,
+			)
+		}
+		name := d.AppName + "-" + s.Name + ".socket"
+		units = append(units, SystemdUnit{Name: name, Content: buf.String()})
+		sockets = append(sockets, name)
+	}
+
+	exe, err := filepath.Abs(d.AppPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to resolve absolute path of %s, reason -> %w",
+			errLoc, d.AppName, err,
+		)
+	}
+	pidFile, err := filepath.Abs(d.PidFile)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to resolve absolute path of PID file, reason -> %w",
+			errLoc, err,
+		)
+	}
+	command := func(action string) string {
+		args := append([]string{exe}, d.daemonArgs()...)
+		args = append(args, action)
+		for i := range args {
+			args[i] = systemdQuote(args[i])
+		}
+		return strings.Join(args, " ")
+	}
+	// relative paths are resolved against current directory,
+	// which is already WorkDir if Daemonize() has been called
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to get working directory, reason -> %w", errLoc, err,
+		)
+	}
+	dependencies := d.dependencies(
+		func(f facility) string { return f.systemd },
+		func(name string) string { return name + ".service" },
+	)
+	data := map[string]interface{}{
+		"Description":        description,
+		"Sockets":            sockets,
+		"Dependencies":       dependencies,
+		"Type":               "simple",
+		"ExecStart":          command(foregroundAction),
+		"ExecStop":           command("stop"),
+		"PidFile":            pidFile,
+		"WorkDir":            workDir,
+		"Umask":              fmt.Sprintf("%04o", d.Umask),
+		"User":               d.User,
+		"Group":              d.Group,
+		"RestartSec":         systemdDuration(d.RestartPolicy.Delay),
+		"TimeoutStopSec":     "infinity",
+		"StartLimitBurst":    d.RestartPolicy.MaxRestarts,
+		"StartLimitInterval": systemdDuration(d.RestartPolicy.Window),
+	}
+	if d.RestartPolicy.Window <= 0 {
+		// restarts are counted without time limit
+		data["StartLimitInterval"] = "infinity"
+	}
+	if timeout := d.StopPolicy.timeout(); timeout > 0 {
+		data["TimeoutStopSec"] = systemdDuration(timeout)
+	}
+	if d.RequireReady {
+		data["Type"] = "notify"
+	}
+	if d.Supervise && d.ReloadSignal != nil {
+		// supervisor restarts worker process on reload
+		data["ExecReload"] = command("reload")
+	}
+	if len(d.SupplementaryGroups) > 0 {
+		data["SupplementaryGroups"] = strings.Join(d.SupplementaryGroups, " ")
+	}
+	for key, path := range map[string]string{
+		"StdoutFile": d.StdoutFile, "StderrFile": d.StderrFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if data[key], err = filepath.Abs(path); err != nil {
+			return nil, fmt.Errorf(
+				"%s: failed to resolve absolute path of log file, "+
+					"reason -> %w", errLoc, err,
+			)
+		}
+	}
+	var buf bytes.Buffer
+	if err = serviceUnitTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf(
+			"%s: failed to render service unit, reason -> %w", errLoc, err,
+		)
+	}
+	units = append([]SystemdUnit{{Name: service, Content: buf.String()}},
+		units...,
+	)
+	return units, nil
+}
+
+// Writes systemd units of daemon (see SystemdUnits())
+// into given directory. Returns paths of written files.
+func InstallSystemd(dir string) ([]string, error) {
+	return stdDaemon().InstallSystemd(dir)
+}
+
+// Writes systemd units of daemon (see SystemdUnits())
+// into given directory. Returns paths of written files.
+func (d *Daemon) InstallSystemd(dir string) ([]string, error) {
+	const errLoc = "daemonigo.InstallSystemd()"
+	units, err := d.SystemdUnits()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errLoc, err)
+	}
+	paths := make([]string, 0, len(units))
+	for _, unit := range units {
+		path := filepath.Join(dir, unit.Name)
+		if err = os.WriteFile(path, []byte(unit.Content), 0644); err != nil {
+			return paths, fmt.Errorf(
+				"%s: failed to write unit file, reason -> %w", errLoc, err,
+			)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// Quotes argument of command line in systemd unit file.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(
+		`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`,
+	).Replace(arg) + `"`
+}
+
+// Formats duration as systemd time span.
+func systemdDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
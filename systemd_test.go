@@ -0,0 +1,46 @@
+package daemonigo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSystemdQuote(t *testing.T) {
+	for _, tc := range []struct {
+		arg, expected string
+	}{
+		{"/usr/bin/app", "/usr/bin/app"},
+		{"-name=value", "-name=value"},
+		{"", `""`},
+		{"two words", `"two words"`},
+		{"100%", "100%%"},
+		{"$HOME", "$$HOME"},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"semi;colon", `"semi;colon"`},
+		{"it's", `"it's"`},
+		{"line\nbreak", `"line\nbreak"`},
+	} {
+		if got := systemdQuote(tc.arg); got != tc.expected {
+			t.Errorf("systemdQuote(%q) = %s; want %s", tc.arg, got, tc.expected)
+		}
+	}
+}
+
+func TestSystemdDuration(t *testing.T) {
+	for _, tc := range []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{10 * time.Second, "10s"},
+		{1500 * time.Millisecond, "1.5s"},
+		{time.Minute, "60s"},
+	} {
+		if got := systemdDuration(tc.duration); got != tc.expected {
+			t.Errorf("systemdDuration(%s) = %s; want %s",
+				tc.duration, got, tc.expected,
+			)
+		}
+	}
+}